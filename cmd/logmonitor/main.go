@@ -0,0 +1,149 @@
+/**
+ * logmonitor continuously checks the seals of tenants' logs for consistency.
+ *
+ * For each tenant, the newest massif seal is verified against a trusted key store, and proven
+ *  consistent with the latest trusted log state, which is kept in a local log state store.
+ *  The first seal checked for a tenant is trusted on first use.
+ *
+ * Any seal that fails its signature, or is not consistent, raises an alert, as an error log line,
+ *  and optionally as a webhook post. With -exit-on-alert, the monitor exits with status 2 on alert.
+ *
+ * Example, checking a public log every 10 minutes:
+ *
+ *   logmonitor -tenants tenant/<uuid> -keys keys.json -state-dir ./state \
+ *     -url https://app.datatrails.ai/verifiabledata -container merklelogs -interval 10m
+ *
+ * Or checking a downloaded copy of the log once:
+ *
+ *   logmonitor -tenants tenant/<uuid> -keys keys.json -state-dir ./state -logs-dir ./logs -once
+ */
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/datatrails/go-datatrails-common/azblob"
+	"github.com/datatrails/go-datatrails-common/logger"
+	"github.com/datatrails/go-datatrails-logverification/logverification"
+)
+
+const (
+	exitAlert = 2
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+
+	tenants := flag.String("tenants", "", "comma separated tenant identities to monitor, e.g. tenant/<uuid>")
+	keysFile := flag.String("keys", "", "the key store file of keys trusted to sign seals")
+	stateDir := flag.String("state-dir", "", "the directory the trusted log states are kept in")
+	logsDir := flag.String("logs-dir", "", "read the logs from this local directory, instead of blob storage")
+	url := flag.String("url", "", "the root url of the blob storage the logs are read from")
+	container := flag.String("container", "merklelogs", "the blob storage container the logs are read from")
+	interval := flag.Duration("interval", logverification.DefaultMonitorInterval, "the time between checks")
+	webhook := flag.String("webhook", "", "post alerts, as json, to this url")
+	massifHeight := flag.Uint("massif-height", 0, "the massif height of the logs, discovered from the logs if 0")
+	once := flag.Bool("once", false, "check every tenant once, then exit")
+	exitOnAlert := flag.Bool("exit-on-alert", false, "exit on the first check that raises an alert")
+	logLevel := flag.String("log-level", "INFO", "the log level")
+	flag.Parse()
+
+	logger.New(*logLevel)
+	defer logger.OnExit()
+
+	if *tenants == "" || *keysFile == "" || *stateDir == "" || (*logsDir == "" && *url == "") {
+		fmt.Fprintln(os.Stderr, "-tenants, -keys, -state-dir and one of -logs-dir or -url are required")
+		flag.Usage()
+		return 1
+	}
+
+	// the massif height is a single byte in the massif header
+	if *massifHeight > math.MaxUint8 {
+		fmt.Fprintf(os.Stderr, "-massif-height must be no more than %d\n", math.MaxUint8)
+		flag.Usage()
+		return 1
+	}
+
+	keyStore, err := logverification.LoadKeyStore(*keysFile)
+	if err != nil {
+		logger.Sugar.Errorf("unable to load the key store: %v", err)
+		return 1
+	}
+
+	store, err := logverification.NewFileSystemLogStateStore(*stateDir)
+	if err != nil {
+		logger.Sugar.Errorf("unable to open the log state store: %v", err)
+		return 1
+	}
+
+	var reader azblob.Reader
+	if *logsDir != "" {
+		reader, err = logverification.NewFileSystemReader(*logsDir)
+	} else {
+		reader, err = azblob.NewReaderNoAuth(logger.Sugar, *url, azblob.WithContainer(*container))
+	}
+	if err != nil {
+		logger.Sugar.Errorf("unable to create the log reader: %v", err)
+		return 1
+	}
+
+	options := []logverification.MonitorOption{
+		logverification.WithMonitorInterval(*interval),
+		logverification.WithMonitorAlerter(logverification.LogAlerter{}),
+		logverification.WithMonitorVerifyOptions(logverification.WithVerifyMassifHeight(uint8(*massifHeight))),
+	}
+
+	if *webhook != "" {
+		options = append(options, logverification.WithMonitorAlerter(logverification.WebhookAlerter{URL: *webhook}))
+	}
+
+	if *exitOnAlert {
+		options = append(options, logverification.WithMonitorExitOnAlert())
+	}
+
+	monitor, err := logverification.NewMonitor(reader, store, keyStore, strings.Split(*tenants, ","), options...)
+	if err != nil {
+		logger.Sugar.Errorf("unable to create the monitor: %v", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *once {
+
+		results := monitor.Check(ctx)
+		for _, result := range results {
+			logger.Sugar.Infof("tenant %s, massif %d, mmr size %d: %s",
+				result.TenantID, result.MassifIndex, result.MMRSize, result.Status)
+		}
+
+		if logverification.Alerted(results) {
+			return exitAlert
+		}
+
+		return 0
+	}
+
+	err = monitor.Run(ctx)
+	if errors.Is(err, logverification.ErrMonitorAlert) {
+		return exitAlert
+	}
+	if err != nil {
+		logger.Sugar.Errorf("the monitor stopped: %v", err)
+		return 1
+	}
+
+	return 0
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"crypto/sha256"
+	"errors"
 	"fmt"
 
 	"github.com/datatrails/go-datatrails-merklelog/massifs"
@@ -44,6 +45,11 @@ const (
 	IDTimestapSizeBytes = 8
 )
 
+var (
+	ErrMMRSizeBeyondMassif = errors.New("the mmr size to prove against is beyond the end of the massif")
+	ErrMMRIndexBeyondSize  = errors.New("the mmr index is beyond the mmr size to prove against")
+)
+
 // MMREntryFields are the fields that when hashed result in the MMR Entry
 type MMREntryFields struct {
 
@@ -100,33 +106,33 @@ func NewAppEntry(
 }
 
 // AppID gets the app id of the corresponding log entry.
-func (ae *AppEntry) AppID() string {
+func (ae AppEntry) AppID() string {
 	return ae.appID
 }
 
 // LogID gets the log id of the corresponding log entry.
-func (ae *AppEntry) LogID() []byte {
+func (ae AppEntry) LogID() []byte {
 	return ae.logID
 }
 
 // Domain gets the domain byte used to derive the mmr entry.
-func (ae *AppEntry) Domain() byte {
+func (ae AppEntry) Domain() byte {
 	return ae.mmrEntryFields.domain
 }
 
 // SerializedBytes gets the serialized bytes used to generate hash of the corresponding mmr entry.
-func (ae *AppEntry) SerializedBytes() []byte {
+func (ae AppEntry) SerializedBytes() []byte {
 	return ae.mmrEntryFields.serializedBytes
 }
 
 // MMRIndex gets the mmr index of the corresponding log entry.
-func (ae *AppEntry) MMRIndex() uint64 {
+func (ae AppEntry) MMRIndex() uint64 {
 	return ae.mmrIndex
 }
 
 // LogTenant returns the Log tenant that committed this app entry to the log
 // as a tenant identity.
-func (ae *AppEntry) LogTenant() (string, error) {
+func (ae AppEntry) LogTenant() (string, error) {
 
 	logTenantUuid, err := uuid.FromBytes(ae.logID)
 	if err != nil {
@@ -138,7 +144,7 @@ func (ae *AppEntry) LogTenant() (string, error) {
 }
 
 // TrieEntry gets the corresponding log trie entry for the app entry.
-func (ae *AppEntry) TrieEntry(massifContext *massifs.MassifContext) ([]byte, error) {
+func (ae AppEntry) TrieEntry(massifContext *massifs.MassifContext) ([]byte, error) {
 
 	trieEntry, err := massifContext.GetTrieEntry(ae.MMRIndex())
 	if err != nil {
@@ -150,7 +156,7 @@ func (ae *AppEntry) TrieEntry(massifContext *massifs.MassifContext) ([]byte, err
 }
 
 // ExtraBytes gets the extrabytes of the corresponding log entry.
-func (ae *AppEntry) ExtraBytes(massifContext *massifs.MassifContext) ([]byte, error) {
+func (ae AppEntry) ExtraBytes(massifContext *massifs.MassifContext) ([]byte, error) {
 
 	trieEntry, err := ae.TrieEntry(massifContext)
 	if err != nil {
@@ -161,7 +167,7 @@ func (ae *AppEntry) ExtraBytes(massifContext *massifs.MassifContext) ([]byte, er
 }
 
 // IDTimestamp gets the idtimestamp of the corresponding log entry.
-func (ae *AppEntry) IDTimestamp(massifContext *massifs.MassifContext) ([]byte, error) {
+func (ae AppEntry) IDTimestamp(massifContext *massifs.MassifContext) ([]byte, error) {
 
 	trieEntry, err := ae.TrieEntry(massifContext)
 	if err != nil {
@@ -175,9 +181,7 @@ func (ae *AppEntry) IDTimestamp(massifContext *massifs.MassifContext) ([]byte, e
 // MMRSalt is the datatrails provided fields included on the MMR Entry.
 //
 // this is (extrabytes | idtimestamp) for any apps that adhere to log entry version 1.
-func (ae *AppEntry) MMRSalt(massifContext *massifs.MassifContext) ([]byte, error) {
-
-	mmrSalt := make([]byte, MMRSaltSize)
+func (ae AppEntry) MMRSalt(massifContext *massifs.MassifContext) ([]byte, error) {
 
 	extraBytes, err := ae.ExtraBytes(massifContext)
 	if err != nil {
@@ -189,80 +193,70 @@ func (ae *AppEntry) MMRSalt(massifContext *massifs.MassifContext) ([]byte, error
 		return nil, err
 	}
 
+	return NewMMRSalt(extraBytes, idTimestamp), nil
+}
+
+// NewMMRSalt creates the MMR Salt of a log entry from its extrabytes and idtimestamp,
+// as found in the trie value of the log entry.
+//
+// this is (extrabytes | idtimestamp) for any apps that adhere to log entry version 1.
+func NewMMRSalt(extraBytes []byte, idTimestamp []byte) []byte {
+
+	mmrSalt := make([]byte, MMRSaltSize)
+
 	copy(mmrSalt[:ExtraBytesSize], extraBytes)
 
 	copy(mmrSalt[ExtraBytesSize:], idTimestamp)
 
-	return mmrSalt, nil
+	return mmrSalt
 }
 
 // MMREntry derives the mmr entry of the corresponding log entry from the app data.
 //
-// MMREntry is:
+// MMREntry is derived by the MMREntryHasher registered for the app domain of the log entry,
+// e.g. for log version 1:
 //   - H( Domain | MMR Salt | Serialized Bytes)
 //
 // The MMR Salt is sourced from the corresponding log entry
-func (ae *AppEntry) MMREntry(massifContext *massifs.MassifContext) ([]byte, error) {
-
-	logVersion0 := true
+func (ae AppEntry) MMREntry(massifContext *massifs.MassifContext) ([]byte, error) {
 
-	extraBytes, err := ae.ExtraBytes(massifContext)
+	// mmr salt
+	mmrSalt, err := ae.MMRSalt(massifContext)
 	if err != nil {
 		return nil, err
 	}
 
-	// the only implementation of log version 0 is assetsv2
-	//  so check for the assetsv2 app domain (0).
-	if extraBytes[0] != 0 {
-		logVersion0 = false
-	}
-
-	if logVersion0 {
-		hasher := LogVersion0Hasher{}
-
-		var idTimestamp []byte
-		idTimestamp, err = ae.IDTimestamp(massifContext)
-		if err != nil {
-			return nil, err
-		}
+	return ae.MMREntryFromSalt(mmrSalt)
+}
 
-		var eventHash []byte
-		eventHash, err = hasher.HashEvent(ae.mmrEntryFields.serializedBytes, idTimestamp)
-		if err != nil {
-			return nil, err
-		}
+// MMREntryFromSalt derives the mmr entry of the corresponding log entry from the app data,
+// given the MMR Salt of the log entry, rather than sourcing it from the log.
+//
+// This allows the mmr entry to be derived without any access to the log, e.g. to verify a receipt.
+//
+// The app domain is the first of the extra bytes in the MMR Salt, and selects the MMREntryHasher.
+func (ae AppEntry) MMREntryFromSalt(mmrSalt []byte) ([]byte, error) {
 
-		return eventHash, nil
+	if len(mmrSalt) != MMRSaltSize {
+		return nil, fmt.Errorf("%w: expected %d, got %d", ErrMMRSaltSize, MMRSaltSize, len(mmrSalt))
 	}
 
-	// if we get here we know its a log version 1 entry
+	hasher := MMREntryHasherFor(mmrSalt[0])
 
-	hasher := sha256.New()
+	return hasher.HashMMREntry(ae.mmrEntryFields.domain, mmrSalt, ae.mmrEntryFields.serializedBytes)
+}
 
-	// domain
-	hasher.Write([]byte{ae.mmrEntryFields.domain})
+// Proof gets the inclusion proof of the corresponding log entry for the app data,
+// against the given mmr size, e.g. the MMRSize of a verified log state.
+//
+// If the given mmr size is 0, the proof is against the end of the massif.
+func (ae AppEntry) Proof(massifContext *massifs.MassifContext, mmrSize uint64) ([][]byte, error) {
 
-	// mmr salt
-	mmrSalt, err := ae.MMRSalt(massifContext)
+	mmrSize, err := ae.proofMMRSize(massifContext, mmrSize)
 	if err != nil {
 		return nil, err
 	}
 
-	hasher.Write(mmrSalt)
-
-	// serialized bytes
-	hasher.Write(ae.mmrEntryFields.serializedBytes)
-
-	return hasher.Sum(nil), nil
-
-}
-
-// Proof gets the inclusion proof of the corresponding log entry for the app data.
-func (ae *AppEntry) Proof(massifContext *massifs.MassifContext) ([][]byte, error) {
-
-	// Get the size of the complete tenant MMR
-	mmrSize := massifContext.RangeCount()
-
 	proof, err := mmr.InclusionProof(massifContext, mmrSize-1, ae.MMRIndex())
 	if err != nil {
 		return nil, err
@@ -271,11 +265,16 @@ func (ae *AppEntry) Proof(massifContext *massifs.MassifContext) ([][]byte, error
 	return proof, nil
 }
 
-// VerifyProof verifies the given inclusion proof of the corresponding log entry for the app data.
-func (ae *AppEntry) VerifyProof(massifContext *massifs.MassifContext, proof [][]byte) (bool, error) {
+// VerifyProof verifies the given inclusion proof of the corresponding log entry for the app data,
+// against the given mmr size, e.g. the MMRSize of a verified log state.
+//
+// If the given mmr size is 0, the proof is verified against the end of the massif.
+func (ae AppEntry) VerifyProof(massifContext *massifs.MassifContext, proof [][]byte, mmrSize uint64) (bool, error) {
 
-	// Get the size of the complete tenant MMR
-	mmrSize := massifContext.RangeCount()
+	mmrSize, err := ae.proofMMRSize(massifContext, mmrSize)
+	if err != nil {
+		return false, err
+	}
 
 	hasher := sha256.New()
 
@@ -290,15 +289,41 @@ func (ae *AppEntry) VerifyProof(massifContext *massifs.MassifContext, proof [][]
 }
 
 // VerifyInclusion verifies the inclusion of the app entry
-// against the corresponding log entry in immutable merkle log
+// against the corresponding log entry in immutable merkle log,
+// for a log of the given mmr size, e.g. the MMRSize of a verified log state.
+//
+// If the given mmr size is 0, the inclusion is verified against the end of the massif.
 //
 // Returns true if the app entry is included on the log, otherwise false.
-func (ae *AppEntry) VerifyInclusion(massifContext *massifs.MassifContext) (bool, error) {
+func (ae AppEntry) VerifyInclusion(massifContext *massifs.MassifContext, mmrSize uint64) (bool, error) {
 
-	proof, err := ae.Proof(massifContext)
+	proof, err := ae.Proof(massifContext, mmrSize)
 	if err != nil {
 		return false, err
 	}
 
-	return ae.VerifyProof(massifContext, proof)
+	return ae.VerifyProof(massifContext, proof, mmrSize)
+}
+
+// proofMMRSize gets the size of the log to prove the app entry against.
+//
+// The massif must hold every node of a log of that size, so it can be no bigger than the massif.
+func (ae AppEntry) proofMMRSize(massifContext *massifs.MassifContext, mmrSize uint64) (uint64, error) {
+
+	// Get the size of the complete tenant MMR
+	massifSize := massifContext.RangeCount()
+
+	if mmrSize == 0 {
+		mmrSize = massifSize
+	}
+
+	if mmrSize > massifSize {
+		return 0, fmt.Errorf("%w: mmr size %d, massif size %d", ErrMMRSizeBeyondMassif, mmrSize, massifSize)
+	}
+
+	if ae.MMRIndex() >= mmrSize {
+		return 0, fmt.Errorf("%w: mmr index %d, mmr size %d", ErrMMRIndexBeyondSize, ae.MMRIndex(), mmrSize)
+	}
+
+	return mmrSize, nil
 }
@@ -184,7 +184,7 @@ func TestAppEntry_VerifyInclusionLogVersion1(t *testing.T) {
 		mmrEntryFields: NewMMREntryFields(0x0, serializedBytes),
 	}
 
-	inclusionVerified, err := ae.VerifyInclusion(testMassifContext)
+	inclusionVerified, err := ae.VerifyInclusion(testMassifContext, 0)
 	assert.NoError(t, err)
 	assert.True(t, inclusionVerified)
 }
@@ -201,7 +201,64 @@ func TestAppEntry_VerifyInclusionLogVersion0(t *testing.T) {
 		mmrEntryFields: NewMMREntryFields(0x0, serializedBytes),
 	}
 
-	inclusionVerified, err := ae.VerifyInclusion(testMassifContext)
+	inclusionVerified, err := ae.VerifyInclusion(testMassifContext, 0)
 	assert.NoError(t, err)
 	assert.True(t, inclusionVerified)
 }
+
+// TestAppEntry_VerifyInclusionMMRSize tests:
+//
+// 1. the inclusion of an app entry can be verified against an earlier mmr size than the massif.
+// 2. an mmr size beyond the end of the massif returns a specific error.
+// 3. an app entry beyond the mmr size returns a specific error.
+func TestAppEntry_VerifyInclusionMMRSize(t *testing.T) {
+	testMassifContext := testMassifContext(t)
+
+	tests := []struct {
+		name     string
+		mmrIndex uint64
+		mmrSize  uint64
+		err      error
+	}{
+		{
+			name:     "earlier mmr size",
+			mmrIndex: 0,
+			mmrSize:  1,
+		},
+		{
+			name:     "end of the massif",
+			mmrIndex: 0,
+			mmrSize:  3,
+		},
+		{
+			name:     "mmr size beyond the massif",
+			mmrIndex: 0,
+			mmrSize:  7,
+			err:      ErrMMRSizeBeyondMassif,
+		},
+		{
+			name:     "mmr index beyond the mmr size",
+			mmrIndex: 1,
+			mmrSize:  1,
+			err:      ErrMMRIndexBeyondSize,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			ae := &AppEntry{
+				mmrIndex:       test.mmrIndex,
+				mmrEntryFields: NewMMREntryFields(0x0, []byte(logVersion0Event)),
+			}
+
+			inclusionVerified, err := ae.VerifyInclusion(testMassifContext, test.mmrSize)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, inclusionVerified)
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/datatrails/go-datatrails-serialization/eventsv1"
+	"github.com/google/uuid"
+)
+
+/**
+ * Creates AppEntries from the json of the DataTrails events APIs.
+ *
+ * Two apps commit entries to the log:
+ *
+ *   * assetsv2 - log version 0 entries, the serialized bytes are the event json itself,
+ *                the log is given by the tenant_identity, and the mmr index by the merklelog_entry.
+ *   * eventsv1 - log version 1 entries, the serialized bytes are the eventsv1 serialization of the event json,
+ *                the log is given by the origin_tenant, and the mmr index by the merklelog_commit.
+ *
+ * Which app an event is from is detected from the merklelog field it has.
+ */
+
+var (
+	ErrEventJSONUnknownApp = errors.New("the event json is neither an assetsv2 nor an eventsv1 event")
+	ErrLogTenantInvalid    = errors.New("the tenant identity is not a valid tenant identity")
+)
+
+// merklelogCommitJSON is the merklelog commit of an event.
+//
+// NOTE: the index is a uint64, so is a string in the json from the API, json.Number accepts either.
+type merklelogCommitJSON struct {
+	Index json.Number `json:"index"`
+}
+
+// eventJSON has the fields of an assetsv2 or eventsv1 event needed for its app entry.
+type eventJSON struct {
+	Identity string `json:"identity"`
+
+	// assetsv2
+	TenantIdentity string `json:"tenant_identity"`
+	MerklelogEntry *struct {
+		Commit *merklelogCommitJSON `json:"commit"`
+	} `json:"merklelog_entry"`
+
+	// eventsv1
+	OriginTenant    string               `json:"origin_tenant"`
+	MerklelogCommit *merklelogCommitJSON `json:"merklelog_commit"`
+}
+
+// NewAppEntryFromJSON creates the app entry for the given event json, as returned by either
+// the assetsv2 or eventsv1 events API.
+func NewAppEntryFromJSON(eventJson []byte) (*AppEntry, error) {
+
+	event := eventJSON{}
+	err := json.Unmarshal(eventJson, &event)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.MerklelogEntry != nil {
+		return newAssetsV2AppEntry(event, eventJson)
+	}
+
+	if event.MerklelogCommit != nil {
+		return newEventsV1AppEntry(event, eventJson)
+	}
+
+	return nil, ErrEventJSONUnknownApp
+}
+
+// NewAppEntryFromAssetsV2JSON creates the app entry for the given event json,
+// as returned by the assetsv2 events API.
+func NewAppEntryFromAssetsV2JSON(eventJson []byte) (*AppEntry, error) {
+
+	event := eventJSON{}
+	err := json.Unmarshal(eventJson, &event)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAssetsV2AppEntry(event, eventJson)
+}
+
+// NewAppEntryFromEventsV1JSON creates the app entry for the given event json,
+// as returned by the eventsv1 events API.
+func NewAppEntryFromEventsV1JSON(eventJson []byte) (*AppEntry, error) {
+
+	event := eventJSON{}
+	err := json.Unmarshal(eventJson, &event)
+	if err != nil {
+		return nil, err
+	}
+
+	return newEventsV1AppEntry(event, eventJson)
+}
+
+// NewAppEntriesFromJSON takes a list of events JSON (e.g. from the events list API), converts them
+// into AppEntries and then returns them sorted by ascending MMR index, ready for list verification.
+//
+// Each event can be from either the assetsv2 or eventsv1 events API.
+func NewAppEntriesFromJSON(eventsJson []byte) ([]AppEntry, error) {
+
+	// get the event list out of events
+	eventListJson := struct {
+		Events []json.RawMessage `json:"events"`
+	}{}
+
+	err := json.Unmarshal(eventsJson, &eventListJson)
+	if err != nil {
+		return nil, err
+	}
+
+	appEntries := []AppEntry{}
+	for _, eventJson := range eventListJson.Events {
+		appEntry, err := NewAppEntryFromJSON(eventJson)
+		if err != nil {
+			return nil, err
+		}
+
+		appEntries = append(appEntries, *appEntry)
+	}
+
+	// Sorting the app entries by MMR index guarantees that they're sorted in log append order.
+	sort.Slice(appEntries, func(i, j int) bool {
+		return appEntries[i].MMRIndex() < appEntries[j].MMRIndex()
+	})
+
+	return appEntries, nil
+}
+
+// LogID gets the log id of the given tenant identity, e.g. "tenant/<uuid>".
+//
+// The log id is the uuid of the tenant in byte form.
+func LogID(tenantIdentity string) ([]byte, error) {
+
+	tenantUUIDStr, ok := strings.CutPrefix(tenantIdentity, "tenant/")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrLogTenantInvalid, tenantIdentity)
+	}
+
+	tenantUUID, err := uuid.Parse(tenantUUIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrLogTenantInvalid, tenantIdentity)
+	}
+
+	return tenantUUID.MarshalBinary()
+}
+
+// newAssetsV2AppEntry creates the log version 0 app entry for the given assetsv2 event.
+func newAssetsV2AppEntry(event eventJSON, eventJson []byte) (*AppEntry, error) {
+
+	if event.MerklelogEntry == nil || event.MerklelogEntry.Commit == nil {
+		return nil, fmt.Errorf("%w: no merklelog_entry commit", ErrEventJSONUnknownApp)
+	}
+
+	return newAppEntry(event.Identity, event.TenantIdentity, event.MerklelogEntry.Commit, eventJson)
+}
+
+// newEventsV1AppEntry creates the log version 1 app entry for the given eventsv1 event.
+func newEventsV1AppEntry(event eventJSON, eventJson []byte) (*AppEntry, error) {
+
+	if event.MerklelogCommit == nil {
+		return nil, fmt.Errorf("%w: no merklelog_commit", ErrEventJSONUnknownApp)
+	}
+
+	serializedBytes, err := eventsv1.SerializeEventFromJson(eventJson)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAppEntry(event.Identity, event.OriginTenant, event.MerklelogCommit, serializedBytes)
+}
+
+// newAppEntry creates the app entry for an event, given its serialized bytes.
+func newAppEntry(
+	identity string,
+	tenantIdentity string,
+	commit *merklelogCommitJSON,
+	serializedBytes []byte,
+) (*AppEntry, error) {
+
+	logID, err := LogID(tenantIdentity)
+	if err != nil {
+		return nil, err
+	}
+
+	mmrIndex, err := strconv.ParseUint(commit.Index.String(), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAppEntry(
+		identity,
+		logID,
+		NewMMREntryFields(LeafTypePlain, serializedBytes),
+		mmrIndex,
+	), nil
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewAppEntryFromJSON tests:
+//
+// 1. assetsv2 and eventsv1 events are detected, and their app entries verify against the log.
+// 2. json from neither app returns a specific error.
+func TestNewAppEntryFromJSON(t *testing.T) {
+
+	testMassifContext := testMassifContext(t)
+
+	tests := []struct {
+		name             string
+		eventJson        string
+		expectedAppID    string
+		expectedMMRIndex uint64
+		err              error
+	}{
+		{
+			name:             "assetsv2",
+			eventJson:        logVersion0Event,
+			expectedAppID:    "assets/899e00a2-29bc-4316-bf70-121ce2044472/events/450dce94-065e-4f6a-bf69-7b59f28716b6",
+			expectedMMRIndex: 0,
+		},
+		{
+			name:             "eventsv1",
+			eventJson:        logVersion1Event,
+			expectedAppID:    "events/01947000-3456-780f-bfa9-29881e3bac88",
+			expectedMMRIndex: 1,
+		},
+		{
+			name:      "unknown app",
+			eventJson: `{"identity": "events/01947000-3456-780f-bfa9-29881e3bac88"}`,
+			err:       ErrEventJSONUnknownApp,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			actual, err := NewAppEntryFromJSON([]byte(test.eventJson))
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedAppID, actual.AppID())
+			assert.Equal(t, test.expectedMMRIndex, actual.MMRIndex())
+
+			logTenant, err := actual.LogTenant()
+			require.NoError(t, err)
+			assert.Equal(t, "tenant/112758ce-a8cb-4924-8df8-fcba1e31f8b0", logTenant)
+
+			verified, err := actual.VerifyInclusion(testMassifContext, 0)
+			require.NoError(t, err)
+			assert.True(t, verified)
+		})
+	}
+}
+
+// TestNewAppEntriesFromJSON tests that a list of mixed app events is returned sorted by mmr index.
+func TestNewAppEntriesFromJSON(t *testing.T) {
+
+	eventsJson := fmt.Sprintf(`{"events": [%s, %s]}`, logVersion1Event, logVersion0Event)
+
+	actual, err := NewAppEntriesFromJSON([]byte(eventsJson))
+	require.NoError(t, err)
+
+	require.Len(t, actual, 2)
+	assert.Equal(t, uint64(0), actual[0].MMRIndex())
+	assert.Equal(t, uint64(1), actual[1].MMRIndex())
+}
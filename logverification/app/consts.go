@@ -5,4 +5,10 @@ const (
 
 	// LeafTypePlain is the domain separator for events
 	LeafTypePlain = uint8(0)
+
+	// AppDomainAssetsV2 is the app domain of assetsv2 log entries, which are log version 0 entries.
+	AppDomainAssetsV2 = byte(0)
+
+	// AppDomainEventsV1 is the app domain of eventsv1 log entries, which are log version 1 entries.
+	AppDomainEventsV1 = byte(1)
 )
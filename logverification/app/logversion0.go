@@ -1,6 +1,8 @@
 package app
 
 import (
+	"fmt"
+
 	"github.com/datatrails/go-datatrails-merklelog/massifs"
 	"github.com/datatrails/go-datatrails-simplehash/simplehash"
 )
@@ -17,6 +19,18 @@ func NewLogVersion0Hasher() *LogVersion0Hasher {
 	return &LogVersion0Hasher{}
 }
 
+// HashMMREntry derives the log version 0 mmr entry, where the serialized bytes are the event json.
+//
+// Only the idtimestamp of the MMR Salt is used, the domain is always the plain leaf domain separator.
+func (h *LogVersion0Hasher) HashMMREntry(domain byte, mmrSalt []byte, serializedBytes []byte) ([]byte, error) {
+
+	if len(mmrSalt) != MMRSaltSize {
+		return nil, fmt.Errorf("%w: expected %d, got %d", ErrMMRSaltSize, MMRSaltSize, len(mmrSalt))
+	}
+
+	return h.HashEvent(serializedBytes, mmrSalt[ExtraBytesSize:])
+}
+
 // HashEvent defines the hashing schema for log version 0 nodes,
 // given the event data in json format.
 //
@@ -30,7 +44,6 @@ func NewLogVersion0Hasher() *LogVersion0Hasher {
 //   - simplehashv3 is the datatrails simplehash v3 schema for hashing datatrails events
 func (h *LogVersion0Hasher) HashEvent(eventJson []byte, idTimestamp []byte) ([]byte, error) {
 
-	simplehashv3Hasher := simplehash.NewHasherV3()
 	v3Event, err := simplehash.V3FromEventJSON(eventJson)
 	if err != nil {
 		return nil, err
@@ -45,7 +58,18 @@ func (h *LogVersion0Hasher) HashEvent(eventJson []byte, idTimestamp []byte) ([]b
 		return nil, err
 	}
 
-	err = simplehashv3Hasher.HashEventFromV3(
+	return h.HashV3Event(v3Event, idCommitted)
+}
+
+// HashV3Event defines the hashing schema for log version 0 nodes,
+// given the already decoded event and its id timestamp.
+//
+// The hashing schema is the same as HashEvent.
+func (h *LogVersion0Hasher) HashV3Event(v3Event simplehash.V3Event, idCommitted uint64) ([]byte, error) {
+
+	simplehashv3Hasher := simplehash.NewHasherV3()
+
+	err := simplehashv3Hasher.HashEventFromV3(
 		v3Event,
 		simplehash.WithPrefix([]byte{LeafTypePlain}),
 		simplehash.WithIDCommitted(idCommitted),
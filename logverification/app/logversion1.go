@@ -0,0 +1,48 @@
+package app
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+/**
+ * Log Version 1 defines the hashing schema used to generate the hash, used
+ *   as a value, of a merkle log node, for any apps other than assetsv2.
+ */
+
+type LogVersion1Hasher struct {
+}
+
+func NewLogVersion1Hasher() *LogVersion1Hasher {
+	return &LogVersion1Hasher{}
+}
+
+// HashMMREntry defines the hashing schema for log version 1 nodes.
+//
+// The hashing schema is as follows:
+//
+// H( Domain | MMR Salt | Serialized Bytes)
+//
+// Where:
+//   - domain is the hashing schema for the mmr entry
+//   - mmr salt is (extrabytes | idtimestamp) from the trie value of the log entry
+//   - serialized bytes are the app provided fields, serialized in a consistent way
+func (h *LogVersion1Hasher) HashMMREntry(domain byte, mmrSalt []byte, serializedBytes []byte) ([]byte, error) {
+
+	if len(mmrSalt) != MMRSaltSize {
+		return nil, fmt.Errorf("%w: expected %d, got %d", ErrMMRSaltSize, MMRSaltSize, len(mmrSalt))
+	}
+
+	hasher := sha256.New()
+
+	// domain
+	hasher.Write([]byte{domain})
+
+	// mmr salt
+	hasher.Write(mmrSalt)
+
+	// serialized bytes
+	hasher.Write(serializedBytes)
+
+	return hasher.Sum(nil), nil
+}
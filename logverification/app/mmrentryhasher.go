@@ -0,0 +1,61 @@
+package app
+
+import (
+	"errors"
+	"sync"
+)
+
+/**
+ * MMR Entry Hashers derive the mmr entry of a log entry from its app data.
+ *
+ * Each app that commits entries to the log has an app domain, which is the first of the
+ *  extra bytes in the trie value of the log entry. The app domain selects the hasher
+ *  for the log entry:
+ *
+ *   * AppDomainAssetsV2 - log version 0, see LogVersion0Hasher.
+ *   * AppDomainEventsV1 - log version 1, see LogVersion1Hasher.
+ *
+ * Hashers for new apps can be added with RegisterMMREntryHasher. Log entries with any other
+ *  non-zero app domain are hashed with LogVersion1Hasher, as they were before the registry.
+ */
+
+var (
+	ErrMMRSaltSize = errors.New("the mmr salt is not the expected size")
+)
+
+// MMREntryHasher derives the mmr entry of a log entry, given the domain and serialized bytes
+// from the app data, and the MMR Salt, (extrabytes | idtimestamp), from the log.
+type MMREntryHasher interface {
+	HashMMREntry(domain byte, mmrSalt []byte, serializedBytes []byte) ([]byte, error)
+}
+
+var (
+	mmrEntryHashersLock sync.RWMutex
+	mmrEntryHashers     = map[byte]MMREntryHasher{
+		AppDomainAssetsV2: NewLogVersion0Hasher(),
+		AppDomainEventsV1: NewLogVersion1Hasher(),
+	}
+)
+
+// RegisterMMREntryHasher registers the given hasher for log entries with the given app domain,
+// replacing any hasher already registered for it.
+func RegisterMMREntryHasher(appDomain byte, hasher MMREntryHasher) {
+	mmrEntryHashersLock.Lock()
+	defer mmrEntryHashersLock.Unlock()
+
+	mmrEntryHashers[appDomain] = hasher
+}
+
+// MMREntryHasherFor gets the hasher registered for log entries with the given app domain,
+// falling back to LogVersion1Hasher if no hasher is registered for it.
+func MMREntryHasherFor(appDomain byte) MMREntryHasher {
+	mmrEntryHashersLock.RLock()
+	defer mmrEntryHashersLock.RUnlock()
+
+	hasher, ok := mmrEntryHashers[appDomain]
+	if !ok {
+		return NewLogVersion1Hasher()
+	}
+
+	return hasher
+}
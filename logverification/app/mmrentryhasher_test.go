@@ -0,0 +1,154 @@
+package app
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAppDomainCustom = byte(2)
+	testAppDomainNone   = byte(3)
+)
+
+// testCustomHasher is an mmr entry hasher for an app other than assetsv2 and eventsv1.
+type testCustomHasher struct{}
+
+func (h *testCustomHasher) HashMMREntry(domain byte, mmrSalt []byte, serializedBytes []byte) ([]byte, error) {
+	hasher := sha256.New()
+	hasher.Write([]byte("custom"))
+	hasher.Write(serializedBytes)
+
+	return hasher.Sum(nil), nil
+}
+
+// testAppDomainMassifContext generates a massif context with a single entry for the given app domain,
+// with the given leaf value.
+func testAppDomainMassifContext(t *testing.T, appDomain byte, leafValue []byte) *massifs.MassifContext {
+
+	start := massifs.MassifStart{
+		MassifHeight: 3,
+	}
+
+	massifContext := &massifs.MassifContext{
+		Start: start,
+		LogBlobContext: massifs.LogBlobContext{
+			BlobPath: "test",
+			Tags:     map[string]string{},
+		},
+	}
+
+	data, err := start.MarshalBinary()
+	require.NoError(t, err)
+
+	massifContext.Data = append(data, massifContext.InitIndexData()...)
+
+	massifContext.Tags["firstindex"] = fmt.Sprintf("%016x", massifContext.Start.FirstIndex)
+
+	extraBytes := make([]byte, ExtraBytesSize)
+	extraBytes[0] = appDomain
+
+	_, err = massifContext.AddHashedLeaf(
+		sha256.New(),
+		0x018d3b472e221464,
+		extraBytes,
+		[]byte("112758ce-a8cb-4924-8df8-fcba1e31f8b0"), // Tenant UUID
+		[]byte("custom/1234"),
+		leafValue,
+	)
+	require.NoError(t, err)
+
+	return massifContext
+}
+
+// testRegisterMMREntryHasher registers the given hasher for the given app domain,
+// restoring the registry when the test finishes.
+func testRegisterMMREntryHasher(t *testing.T, appDomain byte, hasher MMREntryHasher) {
+
+	mmrEntryHashersLock.RLock()
+	previous, registered := mmrEntryHashers[appDomain]
+	mmrEntryHashersLock.RUnlock()
+
+	t.Cleanup(func() {
+		mmrEntryHashersLock.Lock()
+		defer mmrEntryHashersLock.Unlock()
+
+		if registered {
+			mmrEntryHashers[appDomain] = previous
+			return
+		}
+
+		delete(mmrEntryHashers, appDomain)
+	})
+
+	RegisterMMREntryHasher(appDomain, hasher)
+}
+
+// testAppDomainMMRSalt gets the MMR Salt of the single entry of the massif context
+// generated by testAppDomainMassifContext for the given app domain.
+func testAppDomainMMRSalt(t *testing.T, appDomain byte) []byte {
+
+	massifContext := testAppDomainMassifContext(t, appDomain, nil)
+
+	trieEntry, err := massifContext.GetTrieEntry(0)
+	require.NoError(t, err)
+
+	return NewMMRSalt(massifs.GetExtraBytes(trieEntry, 0, 0), massifs.GetIdtimestamp(trieEntry, 0, 0))
+}
+
+// TestAppEntry_MMREntryRegisteredHasher tests:
+//
+// 1. the mmr entry of a log entry is derived by the hasher registered for its app domain.
+// 2. the mmr entry of a log entry with an app domain that has no registered hasher
+// is derived by the log version 1 hasher.
+func TestAppEntry_MMREntryRegisteredHasher(t *testing.T) {
+
+	testRegisterMMREntryHasher(t, testAppDomainCustom, &testCustomHasher{})
+
+	serializedBytes := []byte("its a me, a custom app entry")
+
+	expectedCustom, err := (&testCustomHasher{}).HashMMREntry(0, nil, serializedBytes)
+	require.NoError(t, err)
+
+	expectedFallback, err := NewLogVersion1Hasher().HashMMREntry(
+		0, testAppDomainMMRSalt(t, testAppDomainNone), serializedBytes)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		appDomain byte
+		expected  []byte
+	}{
+		{
+			name:      "registered app domain",
+			appDomain: testAppDomainCustom,
+			expected:  expectedCustom,
+		},
+		{
+			name:      "unregistered app domain falls back to log version 1",
+			appDomain: testAppDomainNone,
+			expected:  expectedFallback,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			massifContext := testAppDomainMassifContext(t, test.appDomain, test.expected)
+
+			ae := NewAppEntry("custom/1234", []byte("1234"), NewMMREntryFields(0, serializedBytes), 0)
+
+			actual, err := ae.MMREntry(massifContext)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, actual)
+
+			verified, err := ae.VerifyInclusion(massifContext, 0)
+			require.NoError(t, err)
+			assert.True(t, verified)
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package logverification
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/datatrails/go-datatrails-common/azblob"
+	"github.com/datatrails/go-datatrails-common/logger"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/datatrails/go-datatrails-merklelog/mmr"
+)
+
+/**
+ * Consistency proofs that can be handed to a third party.
+ *
+ * A consistency proof shows the log at mmr size B is an append only extension of the log at mmr size A.
+ *  It is an inclusion proof, in the log at size B, for each peak of the log at size A.
+ *
+ * The party building the proof needs access to the log. The party verifying it only needs the peaks
+ *  of both log states, e.g. from two verified seals, so a monitoring party can be sent the proof
+ *  instead of the massifs.
+ *
+ * The proof round trips through both JSON and CBOR.
+ */
+
+var (
+	ErrConsistencyProofSizes = errors.New("the consistency proof mmr sizes are not valid, mmr size A must be a valid mmr size no greater than mmr size B")
+	ErrConsistencyProofPeaks = errors.New("the number of peaks does not match the consistency proof")
+)
+
+// ConsistencyProof is a proof that the log at mmr size B is an append only extension
+// of the log at mmr size A.
+type ConsistencyProof struct {
+	TenantID string `json:"tenant_id" cbor:"1,keyasint"`
+
+	MMRSizeA uint64 `json:"mmr_size_a" cbor:"2,keyasint"`
+	MMRSizeB uint64 `json:"mmr_size_b" cbor:"3,keyasint"`
+
+	// Path is the inclusion proof of each peak of the log at mmr size A,
+	// in the log at mmr size B, in the same order as the peaks.
+	Path [][][]byte `json:"path" cbor:"4,keyasint"`
+}
+
+// NewConsistencyProof builds the proof that the given tenant's log at mmr size B is an append only
+// extension of the log at mmr size A.
+//
+// The massifs needed for the proof are read from the log.
+//
+// The options argument can be the following:
+//
+//	WithVerifyMassifHeight - the massif height of the merklelog, instead of
+//	                         discovering it from the log.
+func NewConsistencyProof(
+	ctx context.Context,
+	reader azblob.Reader,
+	tenantID string,
+	mmrSizeA uint64,
+	mmrSizeB uint64,
+	options ...VerifyOption,
+) (*ConsistencyProof, error) {
+
+	verifyOptions := ParseOptions(options...)
+
+	err := validateConsistencySizes(mmrSizeA, mmrSizeB)
+	if err != nil {
+		return nil, err
+	}
+
+	massifReader := massifs.NewMassifReader(logger.Sugar, reader)
+
+	// last massif in the merkle log for mmr size B
+	massifContextB, err := Massif(ctx, mmrSizeB-1, &massifReader, tenantID, verifyOptions.massifHeight)
+	if err != nil {
+		return nil, fmt.Errorf("NewConsistencyProof failed: unable to get the last massif for mmr size B: %w", err)
+	}
+
+	// nodes in earlier massifs are read as they are needed
+	nodeStore := NewMassifNodeStore(ctx, &massifReader, tenantID, verifyOptions.massifHeight)
+	nodeStore.AddMassif(massifContextB)
+
+	proof, err := mmr.IndexConsistencyProof(nodeStore, mmrSizeA-1, mmrSizeB-1)
+	if err != nil {
+		return nil, fmt.Errorf("NewConsistencyProof failed: %w", err)
+	}
+
+	return &ConsistencyProof{
+		TenantID: tenantID,
+		MMRSizeA: proof.MMRSizeA,
+		MMRSizeB: proof.MMRSizeB,
+		Path:     proof.Path,
+	}, nil
+}
+
+// VerifyConsistencyProof verifies the given consistency proof, against the peaks of the log at
+// mmr size A and the peaks of the log at mmr size B, e.g. the Peaks of two verified log states.
+//
+// Only the peaks are needed, so this does not need any access to the log.
+//
+// Returns true if the log at mmr size B is an append only extension of the log at mmr size A,
+// otherwise false, with an error wrapping mmr.ErrConsistencyCheck if the proof does not hold.
+func VerifyConsistencyProof(proof *ConsistencyProof, peaksA [][]byte, peaksB [][]byte) (bool, error) {
+
+	err := validateConsistencySizes(proof.MMRSizeA, proof.MMRSizeB)
+	if err != nil {
+		return false, err
+	}
+
+	// there is a peak for every set bit of the leaf count
+	if len(peaksA) != len(mmr.Peaks(proof.MMRSizeA-1)) || len(peaksA) != len(proof.Path) {
+		return false, fmt.Errorf("%w: mmr size A %d, got %d peaks and %d paths",
+			ErrConsistencyProofPeaks, proof.MMRSizeA, len(peaksA), len(proof.Path))
+	}
+
+	if len(peaksB) != len(mmr.Peaks(proof.MMRSizeB-1)) {
+		return false, fmt.Errorf("%w: mmr size B %d, got %d peaks",
+			ErrConsistencyProofPeaks, proof.MMRSizeB, len(peaksB))
+	}
+
+	verified, _ /*peaksB*/, err := mmr.VerifyConsistency(
+		sha256.New(),
+		mmr.ConsistencyProof{
+			MMRSizeA: proof.MMRSizeA,
+			MMRSizeB: proof.MMRSizeB,
+			Path:     proof.Path,
+		},
+		peaksA,
+		peaksB,
+	)
+
+	return verified, err
+}
+
+// validateConsistencySizes checks both mmr sizes are valid mmr sizes, and mmr size A is
+// no greater than mmr size B.
+func validateConsistencySizes(mmrSizeA uint64, mmrSizeB uint64) error {
+
+	if mmrSizeA == 0 || mmrSizeA > mmrSizeB {
+		return fmt.Errorf("%w: mmr size A %d, mmr size B %d", ErrConsistencyProofSizes, mmrSizeA, mmrSizeB)
+	}
+
+	// not every size is a valid mmr size, as the parents of leaves are added with them
+	if mmr.FirstMMRSize(mmrSizeA-1) != mmrSizeA || mmr.FirstMMRSize(mmrSizeB-1) != mmrSizeB {
+		return fmt.Errorf("%w: mmr size A %d, mmr size B %d", ErrConsistencyProofSizes, mmrSizeA, mmrSizeB)
+	}
+
+	return nil
+}
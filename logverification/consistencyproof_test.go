@@ -0,0 +1,109 @@
+package logverification
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-common/cbor"
+	"github.com/datatrails/go-datatrails-merklelog/mmr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConsistencyProof tests that a consistency proof built from the log, and sent
+// as JSON or CBOR, is verified against only the peaks of the two log states.
+func TestConsistencyProof(t *testing.T) {
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	localLog.AppendEntries(5)
+	logStateA := localLog.Seal()
+
+	localLog.AppendEntries(6) // across a massif boundary
+	logStateB := localLog.Seal()
+
+	proof, err := NewConsistencyProof(
+		context.Background(), localLog.Reader(), localLog.tenantID, logStateA.MMRSize, logStateB.MMRSize)
+	require.NoError(t, err)
+
+	assert.Equal(t, localLog.tenantID, proof.TenantID)
+	assert.Equal(t, logStateA.MMRSize, proof.MMRSizeA)
+	assert.Equal(t, logStateB.MMRSize, proof.MMRSizeB)
+
+	t.Run("json", func(t *testing.T) {
+
+		encoded, err := json.Marshal(proof)
+		require.NoError(t, err)
+
+		decoded := &ConsistencyProof{}
+		err = json.Unmarshal(encoded, decoded)
+		require.NoError(t, err)
+
+		verified, err := VerifyConsistencyProof(decoded, logStateA.Peaks, logStateB.Peaks)
+		require.NoError(t, err)
+		assert.True(t, verified)
+	})
+
+	t.Run("cbor", func(t *testing.T) {
+
+		codec, err := cbor.NewCBORCodec(cbor.NewDeterministicEncOpts(), cbor.NewDeterministicDecOpts())
+		require.NoError(t, err)
+
+		encoded, err := codec.MarshalCBOR(proof)
+		require.NoError(t, err)
+
+		decoded := &ConsistencyProof{}
+		err = codec.UnmarshalInto(encoded, decoded)
+		require.NoError(t, err)
+
+		verified, err := VerifyConsistencyProof(decoded, logStateA.Peaks, logStateB.Peaks)
+		require.NoError(t, err)
+		assert.True(t, verified)
+	})
+
+	tamperedPeaksA := [][]byte{sha256.New().Sum(nil)}
+	tamperedPeaksA = append(tamperedPeaksA, logStateA.Peaks[1:]...)
+
+	tests := []struct {
+		name   string
+		proof  *ConsistencyProof
+		peaksA [][]byte
+		peaksB [][]byte
+		err    error
+	}{
+		{
+			name:   "tampered peaks A",
+			proof:  proof,
+			peaksA: tamperedPeaksA,
+			peaksB: logStateB.Peaks,
+			err:    mmr.ErrConsistencyCheck,
+		},
+		{
+			name:   "peaks B for a different size",
+			proof:  proof,
+			peaksA: logStateA.Peaks,
+			peaksB: logStateA.Peaks,
+			err:    ErrConsistencyProofPeaks,
+		},
+		{
+			name: "not a valid mmr size",
+			proof: &ConsistencyProof{
+				MMRSizeA: 2,
+				MMRSizeB: logStateB.MMRSize,
+				Path:     proof.Path,
+			},
+			peaksA: logStateA.Peaks,
+			peaksB: logStateB.Peaks,
+			err:    ErrConsistencyProofSizes,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			verified, err := VerifyConsistencyProof(test.proof, test.peaksA, test.peaksB)
+			assert.ErrorIs(t, err, test.err)
+			assert.False(t, verified)
+		})
+	}
+}
@@ -5,18 +5,54 @@ import (
 	"sort"
 
 	"github.com/datatrails/go-datatrails-common-api-gen/assets/v2/assets"
+	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
 	"github.com/datatrails/go-datatrails-simplehash/simplehash"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
-// DecodedEvent
+// DecodedEvent is an assetsv2 event, decoded from the events API json.
+//
+// A *DecodedEvent is a VerifiableAppEntry, so its inclusion can be verified in the same way
+// as an app.AppEntry, e.g. with VerifyInclusion or VerifyList.
 type DecodedEvent struct {
 	V3Event   simplehash.V3Event
 	MerkleLog *assets.MerkleLogEntry
 }
 
+// AppID gets the app id of the corresponding log entry, which is the event identity.
+func (e *DecodedEvent) AppID() string {
+	return e.V3Event.Identity
+}
+
+// MMRIndex gets the mmr index of the corresponding log entry, from the event's merklelog commit.
+func (e *DecodedEvent) MMRIndex() uint64 {
+	return e.MerkleLog.GetCommit().GetIndex()
+}
+
+// LogTenant gets the tenant identity of the log the corresponding log entry is on.
+func (e *DecodedEvent) LogTenant() (string, error) {
+	return e.V3Event.TenantIdentity, nil
+}
+
+// MMREntry derives the log version 0 mmr entry of the corresponding log entry from the event.
+//
+// The idtimestamp is the one committed to in the event's merklelog commit, so nothing is sourced
+// from the given massif. An event with the wrong idtimestamp will not verify against the log.
+func (e *DecodedEvent) MMREntry(massifContext *massifs.MassifContext) ([]byte, error) {
+
+	idCommitted, _, err := massifs.SplitIDTimestampHex(e.MerkleLog.GetCommit().GetIdtimestamp())
+	if err != nil {
+		return nil, err
+	}
+
+	return app.NewLogVersion0Hasher().HashV3Event(e.V3Event, idCommitted)
+}
+
 // NewDecodedEvents takes a list of events JSON (e.g. from the events list API), converts them
 // into DecodedEvents and then returns them sorted by ascending MMR index.
+//
+// The whole list is held in memory, for lists too large for that, see VerifyListStream.
 func NewDecodedEvents(eventsJson []byte) ([]DecodedEvent, error) {
 	// get the event list out of events
 	eventListJson := struct {
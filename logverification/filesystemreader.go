@@ -0,0 +1,128 @@
+package logverification
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/datatrails/go-datatrails-common/azblob"
+)
+
+/**
+ * File System Reader serves merklelog blobs (massifs and seals) from a local directory,
+ *   so that a downloaded copy of a tenant's log can be verified fully offline.
+ *
+ * The directory is expected to be laid out exactly as the blob store is, e.g.
+ *
+ *   <root>/v1/mmrs/tenant/<uuid>/0/massifs/0000000000000000.log
+ *   <root>/v1/mmrs/tenant/<uuid>/0/massifseals/0000000000000000.sth
+ *
+ * Which are the paths given by massifs.TenantMassifBlobPath and massifs.TenantMassifSignedRootPath.
+ */
+
+var (
+	ErrNotADirectory         = errors.New("the file system reader root is not a directory")
+	ErrBlobPathNotLocal      = errors.New("the blob path does not resolve to a location under the file system reader root")
+	ErrFileSystemBlobMissing = errors.New("the blob was not found on the local file system")
+	ErrListNotSupported      = errors.New("listing blobs is not supported by the file system reader")
+)
+
+// FileSystemReader is an azblob.Reader that reads blobs from a local directory.
+//
+// Only point reads are supported, which is all that is needed to verify
+// the log given a tenant and massif index.
+type FileSystemReader struct {
+
+	// rootDir is the directory that blob paths are relative to.
+	rootDir string
+}
+
+// NewFileSystemReader creates a new file system reader, serving blobs
+// from the given root directory.
+func NewFileSystemReader(rootDir string) (*FileSystemReader, error) {
+
+	info, err := os.Stat(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrNotADirectory, rootDir)
+	}
+
+	return &FileSystemReader{
+		rootDir: rootDir,
+	}, nil
+}
+
+// BlobFilePath returns the local file path for the given blob path.
+func (r *FileSystemReader) BlobFilePath(blobPath string) (string, error) {
+
+	localPath := filepath.FromSlash(blobPath)
+
+	// guard against blob paths escaping the root directory
+	if !filepath.IsLocal(localPath) {
+		return "", fmt.Errorf("%w: %s", ErrBlobPathNotLocal, blobPath)
+	}
+
+	return filepath.Join(r.rootDir, localPath), nil
+}
+
+// Reader reads the blob at the given blob path (identity) from the local file system.
+//
+// The whole blob is read into memory, as the merklelog readers do not close
+// the reader on the response.
+//
+// NOTE: the azblob options are ignored, tags and metadata are not available locally.
+func (r *FileSystemReader) Reader(
+	ctx context.Context,
+	identity string,
+	opts ...azblob.Option,
+) (*azblob.ReaderResponse, error) {
+
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	filePath, err := r.BlobFilePath(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrFileSystemBlobMissing, identity)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	lastModified := info.ModTime()
+
+	return &azblob.ReaderResponse{
+		Reader:        io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Size:          int64(len(data)),
+		LastModified:  &lastModified,
+	}, nil
+}
+
+// FilteredList is not supported by the file system reader.
+func (r *FileSystemReader) FilteredList(ctx context.Context, tagsFilter string, opts ...azblob.Option) (*azblob.FilterResponse, error) {
+	return nil, ErrListNotSupported
+}
+
+// List is not supported by the file system reader.
+func (r *FileSystemReader) List(ctx context.Context, opts ...azblob.Option) (*azblob.ListerResponse, error) {
+	return nil, ErrListNotSupported
+}
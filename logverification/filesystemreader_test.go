@@ -0,0 +1,167 @@
+package logverification
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewFileSystemReader tests:
+//
+// 1. a directory can be used as the root of a file system reader.
+// 2. a file can not be used as the root of a file system reader.
+// 3. a missing directory can not be used as the root of a file system reader.
+func TestNewFileSystemReader(t *testing.T) {
+
+	rootDir := t.TempDir()
+
+	rootFile := filepath.Join(rootDir, "notadir")
+	err := os.WriteFile(rootFile, []byte("foo"), 0o600)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		rootDir string
+		err     error
+	}{
+		{
+			name:    "positive",
+			rootDir: rootDir,
+			err:     nil,
+		},
+		{
+			name:    "file is not a directory",
+			rootDir: rootFile,
+			err:     ErrNotADirectory,
+		},
+		{
+			name:    "missing directory",
+			rootDir: filepath.Join(rootDir, "missing"),
+			err:     os.ErrNotExist,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewFileSystemReader(test.rootDir)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+// TestFileSystemReader_Reader tests:
+//
+// 1. a blob written under the root directory can be read back.
+// 2. a missing blob returns a specific error.
+// 3. a blob path that escapes the root directory returns a specific error.
+func TestFileSystemReader_Reader(t *testing.T) {
+
+	rootDir := t.TempDir()
+
+	blobPath := massifs.TenantMassifBlobPath(testLocalLogTenantID, 0)
+	blobFile := filepath.Join(rootDir, filepath.FromSlash(blobPath))
+
+	err := os.MkdirAll(filepath.Dir(blobFile), 0o755)
+	require.NoError(t, err)
+
+	err = os.WriteFile(blobFile, []byte("massif data"), 0o600)
+	require.NoError(t, err)
+
+	reader, err := NewFileSystemReader(rootDir)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		blobPath string
+		expected []byte
+		err      error
+	}{
+		{
+			name:     "positive",
+			blobPath: blobPath,
+			expected: []byte("massif data"),
+			err:      nil,
+		},
+		{
+			name:     "missing blob",
+			blobPath: massifs.TenantMassifBlobPath(testLocalLogTenantID, 1),
+			expected: nil,
+			err:      ErrFileSystemBlobMissing,
+		},
+		{
+			name:     "blob path escapes the root directory",
+			blobPath: "../" + blobPath,
+			expected: nil,
+			err:      ErrBlobPathNotLocal,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, actual, err := massifs.BlobRead(context.Background(), test.blobPath, reader)
+
+			assert.ErrorIs(t, err, test.err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+// TestFileSystemReader_VerifyList verifies a list of app entries fully offline,
+// against a log stored on the local file system.
+func TestFileSystemReader_VerifyList(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+	appEntries := localLog.AppendEntries(8)
+
+	// drop an app entry from the middle of the list, so that it is omitted
+	appEntries = append(appEntries[:3], appEntries[4:]...)
+
+	omittedMMRIndices, _, err := VerifyList(context.Background(), localLog.Reader(), appEntries)
+	require.NoError(t, err)
+
+	assert.Equal(t, []uint64{4}, omittedMMRIndices)
+}
+
+// TestFileSystemReader_SignedLogState gets the signed state of a log stored on the local
+// file system, and checks the signature against the recomputed peaks.
+func TestFileSystemReader_SignedLogState(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+	localLog.AppendEntries(5)
+	expectedState := localLog.Seal()
+
+	signedState, err := SignedLogState(
+		context.Background(), localLog.Reader(), sha256.New(), localLog.codec, localLog.tenantID, 0)
+	require.NoError(t, err)
+
+	err = signedState.VerifyWithPublicKey(&localLog.signingKey.PublicKey, nil)
+	require.NoError(t, err)
+
+	logState, err := LogState(signedState, localLog.codec)
+	require.NoError(t, err)
+
+	assert.Equal(t, expectedState.MMRSize, logState.MMRSize)
+	assert.Equal(t, expectedState.Peaks, logState.Peaks)
+}
+
+// TestFileSystemReader_VerifyConsistency verifies the consistency of two log states
+// of a log stored on the local file system.
+func TestFileSystemReader_VerifyConsistency(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+
+	localLog.AppendEntries(3)
+	logStateA := localLog.Seal()
+
+	localLog.AppendEntries(4)
+	logStateB := localLog.Seal()
+
+	verified, err := VerifyConsistency(
+		context.Background(), sha256.New(), localLog.Reader(), localLog.tenantID, logStateA, logStateB)
+	require.NoError(t, err)
+	assert.True(t, verified)
+}
@@ -0,0 +1,404 @@
+package logverification
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/datatrails/go-datatrails-common/azblob"
+	"github.com/datatrails/go-datatrails-common/cbor"
+	"github.com/datatrails/go-datatrails-common/cose"
+	"github.com/datatrails/go-datatrails-common/logger"
+	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/datatrails/go-datatrails-merklelog/mmr"
+)
+
+/**
+ * Index exclusion attestation, using the trie index.
+ *
+ * Every leaf on the log has a companion trie entry, whose trie key is:
+ *
+ * H( Domain | LogId | AppId )
+ *
+ * An app entry is not indexed in a range of the log if no trie entry, for any leaf
+ *  in the range, has the trie key of the app entry.
+ *
+ * An index exclusion attests the log's trie index has no entry for an app entry over a range.
+ *  It holds a seal of the log, that covers the whole range, and for every leaf in the range,
+ *  in leaf order, its trie entry, its mmr entry, and the inclusion proof of the mmr entry against
+ *  the peaks of the seal. So it can be checked without any access to the log:
+ *
+ *  1. the seal verifies against a trusted key, and covers the range.
+ *  2. there is exactly one leaf for every leaf index in the range.
+ *  3. every mmr entry is included, at its leaf, under the peaks of the seal.
+ *  4. none of the trie entries has the trie key of the app entry.
+ *
+ * NOTE: this is an attestation, NOT a proof that an app entry was never committed to the log.
+ *       The seal commits to the mmr entries of the range, but nothing commits to the trie entries,
+ *       they are unsigned, and not bound to the mmr entries. Whoever produces the attestation can
+ *       give any trie entry for a leaf, e.g. change the trie key of the leaf the app entry is on,
+ *       and it still verifies. So it is only as trustworthy as its producer, e.g. the log operator
+ *       attesting to its own index, or a verifier reading the index from a log they trust.
+ */
+
+var (
+	ErrExclusionRangeInvalid     = errors.New("the lower mmr index of the exclusion range is greater than the upper mmr index")
+	ErrExclusionRangeBeyondLog   = errors.New("the exclusion range extends beyond the end of the sealed log")
+	ErrExclusionRangeNoLeaves    = errors.New("the exclusion range has no leaves")
+	ErrAppEntryIndexed           = errors.New("the app entry is in the trie index of the log within the exclusion range")
+	ErrIndexExclusionIncomplete  = errors.New("the index exclusion does not have a leaf for every leaf in its range")
+	ErrIndexExclusionLogMismatch = errors.New("the index exclusion is not for the given log")
+	ErrIndexExclusionLeaf        = errors.New("the index exclusion has a leaf that is not included under the peaks of its seal")
+	ErrExclusionRangeNotCovered  = errors.New("the index exclusion does not cover every leaf in the exclusion range")
+)
+
+// IndexExclusion attests the trie index of a log has no trie entry for an app entry,
+// for any leaf within a sealed range of the log.
+//
+// NOTE: the trie entries are not signed, see the package NOTE on index exclusion.
+type IndexExclusion struct {
+
+	// LogID is the uuid in byte form of the log the range is on.
+	LogID []byte `json:"log_id"`
+
+	// FirstLeafIndex is the leaf index of the first leaf in the range.
+	FirstLeafIndex uint64 `json:"first_leaf_index"`
+
+	// LastLeafIndex is the leaf index of the last leaf in the range.
+	LastLeafIndex uint64 `json:"last_leaf_index"`
+
+	// Seal is the COSE Sign1 seal of the log state the mmr entries are proven against, serialized to CBOR,
+	// with the peaks recomputed from the log as its payload, as returned by SignedLogState.
+	Seal []byte `json:"seal"`
+
+	// Leaves are every leaf in the range, in leaf order.
+	Leaves []IndexExclusionLeaf `json:"leaves"`
+}
+
+// IndexExclusionLeaf is a leaf in the range of an index exclusion.
+type IndexExclusionLeaf struct {
+
+	// TrieEntry is the trie entry of the leaf, (trie key | extrabytes | idtimestamp).
+	//
+	// NOTE: unlike the mmr entry, the trie entry is not committed to by the seal.
+	TrieEntry []byte `json:"trie_entry"`
+
+	// MMREntry is the mmr entry (leaf hash) of the leaf.
+	MMREntry []byte `json:"mmr_entry"`
+
+	// InclusionProof is the inclusion proof of the mmr entry, against the mmr size of the seal.
+	InclusionProof [][]byte `json:"inclusion_proof"`
+}
+
+// AttestIndexExclusion attests the trie index of the log with the given log id has no trie entry
+// for the app entry with the given app id, e.g. event identity, over the given range of mmr indexes.
+//
+// The trie index is read from the given reader, so the attestation is only as trustworthy as
+// the log it is read from, it does not prove the app entry was never committed to the log.
+//
+// The range is inclusive of both the lower and upper mmr index, and covers every leaf within it.
+// The mmr entries of the leaves are proven against the newest seal of the log, which must cover
+// the whole range, otherwise ErrExclusionRangeBeyondLog is returned.
+//
+// If the app entry is in the trie index within the range, ErrAppEntryIndexed is returned,
+// along with the mmr index of the leaf it is on.
+//
+// The options argument can be the following:
+//
+//	WithVerifyMassifHeight - the massif height of the merklelog, instead of
+//	                         discovering it from the log.
+func AttestIndexExclusion(
+	ctx context.Context,
+	reader azblob.Reader,
+	appID string,
+	logID []byte,
+	lowerMMRIndex uint64,
+	upperMMRIndex uint64,
+	options ...VerifyOption,
+) (*IndexExclusion, error) {
+
+	verifyOptions := ParseOptions(options...)
+
+	tenantID, err := app.NewAppEntry(appID, logID, nil, 0).LogTenant()
+	if err != nil {
+		return nil, err
+	}
+
+	firstLeafIndex, lastLeafIndex, err := exclusionLeafRange(lowerMMRIndex, upperMMRIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := massifs.NewRootSignerCodec()
+	if err != nil {
+		return nil, err
+	}
+
+	massifReader := massifs.NewMassifReader(logger.Sugar, reader)
+
+	signedState, logState, err := exclusionSeal(
+		ctx, reader, &massifReader, codec, tenantID, upperMMRIndex, verifyOptions.massifHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	seal, err := signedState.MarshalCBOR()
+	if err != nil {
+		return nil, fmt.Errorf("AttestIndexExclusion failed: unable to cbor encode the seal: %w", err)
+	}
+
+	trieKey := massifs.NewTrieKey(massifs.KeyTypeApplicationContent, logID, []byte(appID))
+
+	// the inclusion proofs of leaves in earlier massifs need nodes from the later massifs
+	nodeStore := NewMassifNodeStore(ctx, &massifReader, tenantID, verifyOptions.massifHeight)
+
+	indexExclusion := &IndexExclusion{
+		LogID:          logID,
+		FirstLeafIndex: firstLeafIndex,
+		LastLeafIndex:  lastLeafIndex,
+		Seal:           seal,
+		Leaves:         []IndexExclusionLeaf{},
+	}
+
+	var massifContext *massifs.MassifContext
+
+	for leafIndex := firstLeafIndex; leafIndex <= lastLeafIndex; leafIndex++ {
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		leafMMRIndex := mmr.MMRIndex(leafIndex)
+
+		// only read a massif when the leaf is not in the current one
+		if massifContext == nil || leafMMRIndex >= massifContext.RangeCount() {
+
+			massifContext, err = Massif(ctx, leafMMRIndex, &massifReader, tenantID, verifyOptions.massifHeight)
+			if err != nil {
+				return nil, fmt.Errorf("AttestIndexExclusion failed: unable to get the massif for mmr index %d: %w",
+					leafMMRIndex, err)
+			}
+
+			// the nodes of earlier massifs that the proofs need are in the ancestor peak stack
+			//  of this one, so the earlier massifs are no longer needed.
+			nodeStore.AddMassif(massifContext)
+			if massifContext.Start.MassifIndex > 0 {
+				nodeStore.EvictTo(uint64(massifContext.Start.MassifIndex) - 1)
+			}
+		}
+
+		trieEntry, err := massifContext.GetTrieEntry(leafMMRIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		if bytes.Equal(trieEntry[:massifs.TrieKeyEnd], trieKey) {
+			return nil, fmt.Errorf("%w: app id %s, mmr index %d", ErrAppEntryIndexed, appID, leafMMRIndex)
+		}
+
+		mmrEntry, err := massifContext.Get(leafMMRIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		proof, err := mmr.InclusionProof(nodeStore, logState.MMRSize-1, leafMMRIndex)
+		if err != nil {
+			return nil, fmt.Errorf("AttestIndexExclusion failed: unable to get the inclusion proof for mmr index %d: %w",
+				leafMMRIndex, err)
+		}
+
+		// copy, as the trie entry and mmr entry are slices of the massif data
+		indexExclusion.Leaves = append(indexExclusion.Leaves, IndexExclusionLeaf{
+			TrieEntry:      bytes.Clone(trieEntry),
+			MMREntry:       bytes.Clone(mmrEntry),
+			InclusionProof: proof,
+		})
+	}
+
+	return indexExclusion, nil
+}
+
+// VerifyIndexExclusion verifies the given index exclusion attests the trie index of the log with
+// the given log id has no trie entry for the app entry with the given app id, e.g. event identity,
+// over the given range of mmr indexes.
+//
+// The range is inclusive of both the lower and upper mmr index, in the same way as AttestIndexExclusion,
+// and every leaf within it must be in the range of the index exclusion, otherwise
+// ErrExclusionRangeNotCovered is returned.
+//
+// The seal of the index exclusion is verified against the given key store, and the mmr entry of every
+// leaf in its range must be included under the peaks of the seal. The trie entries are not signed,
+// so are taken on trust from the producer of the index exclusion.
+//
+// No access to the log is needed.
+func VerifyIndexExclusion(
+	indexExclusion *IndexExclusion,
+	appID string,
+	logID []byte,
+	lowerMMRIndex uint64,
+	upperMMRIndex uint64,
+	keyStore KeyStore,
+) error {
+
+	if !bytes.Equal(indexExclusion.LogID, logID) {
+		return ErrIndexExclusionLogMismatch
+	}
+
+	if indexExclusion.FirstLeafIndex > indexExclusion.LastLeafIndex {
+		return ErrExclusionRangeInvalid
+	}
+
+	firstLeafIndex, lastLeafIndex, err := exclusionLeafRange(lowerMMRIndex, upperMMRIndex)
+	if err != nil {
+		return err
+	}
+
+	// the producer picks the range of the index exclusion, so it must cover the range asked about
+	if indexExclusion.FirstLeafIndex > firstLeafIndex || indexExclusion.LastLeafIndex < lastLeafIndex {
+		return fmt.Errorf("%w: leaves %d to %d, index exclusion covers leaves %d to %d", ErrExclusionRangeNotCovered,
+			firstLeafIndex, lastLeafIndex, indexExclusion.FirstLeafIndex, indexExclusion.LastLeafIndex)
+	}
+
+	codec, err := massifs.NewRootSignerCodec()
+	if err != nil {
+		return err
+	}
+
+	signedState, err := cose.NewCoseSign1MessageFromCBOR(
+		indexExclusion.Seal, cose.WithDecOptions(massifs.CheckpointDecOptions()))
+	if err != nil {
+		return fmt.Errorf("VerifyIndexExclusion failed: unable to decode the seal: %w", err)
+	}
+
+	logState, err := VerifySignedLogState(signedState, codec, keyStore)
+	if err != nil {
+		return err
+	}
+
+	lastMMRIndex := mmr.MMRIndex(indexExclusion.LastLeafIndex)
+	if lastMMRIndex >= logState.MMRSize {
+		return fmt.Errorf("%w: mmr index %d, sealed mmr size %d",
+			ErrExclusionRangeBeyondLog, lastMMRIndex, logState.MMRSize)
+	}
+
+	leafCount := indexExclusion.LastLeafIndex - indexExclusion.FirstLeafIndex + 1
+	if uint64(len(indexExclusion.Leaves)) != leafCount {
+		return fmt.Errorf("%w: expected %d leaves, got %d",
+			ErrIndexExclusionIncomplete, leafCount, len(indexExclusion.Leaves))
+	}
+
+	trieKey := massifs.NewTrieKey(massifs.KeyTypeApplicationContent, logID, []byte(appID))
+
+	for i, leaf := range indexExclusion.Leaves {
+
+		leafMMRIndex := mmr.MMRIndex(indexExclusion.FirstLeafIndex + uint64(i))
+
+		if len(leaf.TrieEntry) != massifs.TrieEntryBytes {
+			return fmt.Errorf("%w: trie entry %d is %d bytes", ErrIndexExclusionIncomplete, i, len(leaf.TrieEntry))
+		}
+
+		_, err := VerifyProofInPeaks(
+			leaf.MMREntry, leafMMRIndex, logState.MMRSize, leaf.InclusionProof, logState.Peaks)
+		if err != nil {
+			return fmt.Errorf("%w: mmr index %d: %w", ErrIndexExclusionLeaf, leafMMRIndex, err)
+		}
+
+		if bytes.Equal(leaf.TrieEntry[:massifs.TrieKeyEnd], trieKey) {
+			return fmt.Errorf("%w: app id %s, mmr index %d", ErrAppEntryIndexed, appID, leafMMRIndex)
+		}
+	}
+
+	return nil
+}
+
+// exclusionLeafRange gets the first and last leaf index within the given range of mmr indexes,
+// which is inclusive of both the lower and upper mmr index.
+func exclusionLeafRange(lowerMMRIndex uint64, upperMMRIndex uint64) (uint64, uint64, error) {
+
+	if lowerMMRIndex > upperMMRIndex {
+		return 0, 0, fmt.Errorf("%w: %d > %d", ErrExclusionRangeInvalid, lowerMMRIndex, upperMMRIndex)
+	}
+
+	// NOTE: LeafCount takes an mmr size, so the leaf count at the lower mmr index
+	//       is the number of leaves before it, which is the first leaf in the range.
+	firstLeafIndex := mmr.LeafCount(lowerMMRIndex)
+	upperLeafCount := mmr.LeafCount(upperMMRIndex + 1)
+
+	if upperLeafCount <= firstLeafIndex {
+		return 0, 0, fmt.Errorf("%w: %d to %d", ErrExclusionRangeNoLeaves, lowerMMRIndex, upperMMRIndex)
+	}
+
+	return firstLeafIndex, upperLeafCount - 1, nil
+}
+
+// exclusionSeal gets the newest seal of the given tenant's log, from the massif of the given
+// mmr index onwards, and checks it covers the mmr index.
+//
+// Returns the signed log state, with its peaks recomputed from the log, and the unsigned log state.
+func exclusionSeal(
+	ctx context.Context,
+	reader azblob.Reader,
+	massifReader MassifGetter,
+	codec cbor.CBORCodec,
+	tenantID string,
+	mmrIndex uint64,
+	massifHeight uint8,
+) (*cose.CoseSign1Message, *massifs.MMRState, error) {
+
+	massifContext, err := Massif(ctx, mmrIndex, massifReader, tenantID, massifHeight)
+	if isBlobNotFound(err) {
+		return nil, nil, fmt.Errorf("%w: mmr index %d: %w", ErrExclusionRangeBeyondLog, mmrIndex, err)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("AttestIndexExclusion failed: unable to get the massif for mmr index %d: %w", mmrIndex, err)
+	}
+
+	// a later seal covers every massif before it, so find the newest sealed massif.
+	//
+	// Only the seals are read. The massif of the mmr index may have filled up between seals,
+	//  so have no seal of its own, but the first massif after it with no seal is the head
+	//  massif, or beyond the end of the log, so ends the search.
+	sealedIndex := uint64(0)
+	sealed := false
+
+	for massifIndex := uint64(massifContext.Start.MassifIndex); ; massifIndex++ {
+
+		massifSealed, err := MassifSealed(ctx, reader, codec, tenantID, massifIndex)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !massifSealed && massifIndex > uint64(massifContext.Start.MassifIndex) {
+			break
+		}
+
+		if massifSealed {
+			sealedIndex = massifIndex
+			sealed = true
+		}
+	}
+
+	if !sealed {
+		return nil, nil, fmt.Errorf("%w: mmr index %d is not sealed", ErrExclusionRangeBeyondLog, mmrIndex)
+	}
+
+	signedState, err := SignedLogState(ctx, reader, sha256.New(), codec, tenantID, sealedIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logState, err := LogState(signedState, codec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if mmrIndex >= logState.MMRSize {
+		return nil, nil, fmt.Errorf("%w: mmr index %d, sealed mmr size %d",
+			ErrExclusionRangeBeyondLog, mmrIndex, logState.MMRSize)
+	}
+
+	return signedState, logState, nil
+}
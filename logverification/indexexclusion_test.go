@@ -0,0 +1,262 @@
+package logverification
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-merklelog/mmr"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAttestIndexExclusion tests that index exclusions are only made for app entries
+// not in the trie index within the range, across massifs.
+func TestAttestIndexExclusion(t *testing.T) {
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	appEntries := localLog.AppendEntries(7) // 2 massifs of 4 leaves
+	localLog.Seal()
+
+	// the last leaf of massif 1 is not sealed
+	localLog.AppendEntries(1)
+
+	keyStore := TrustedKeys{localLog.KeyID(): &localLog.signingKey.PublicKey}
+
+	neverCommitted := fmt.Sprintf("events/%s", uuid.NewString())
+	lastMMRIndex := mmr.MMRIndex(6)
+
+	tests := []struct {
+		name          string
+		appID         string
+		lowerMMRIndex uint64
+		upperMMRIndex uint64
+		leafCount     int
+		err           error
+	}{
+		{
+			name:          "never committed, whole log",
+			appID:         neverCommitted,
+			lowerMMRIndex: 0,
+			upperMMRIndex: lastMMRIndex,
+			leafCount:     7,
+		},
+		{
+			name:          "committed outside the range",
+			appID:         appEntries[0].AppID(),
+			lowerMMRIndex: 1,
+			upperMMRIndex: lastMMRIndex,
+			leafCount:     6,
+		},
+		{
+			name:          "committed within the range",
+			appID:         appEntries[5].AppID(),
+			lowerMMRIndex: 0,
+			upperMMRIndex: lastMMRIndex,
+			err:           ErrAppEntryIndexed,
+		},
+		{
+			name:          "range in an unsealed massif, covered by a later seal",
+			appID:         neverCommitted,
+			lowerMMRIndex: 0,
+			upperMMRIndex: mmr.MMRIndex(3),
+			leafCount:     4,
+		},
+		{
+			name:          "range beyond the sealed log",
+			appID:         neverCommitted,
+			lowerMMRIndex: 0,
+			upperMMRIndex: mmr.MMRIndex(7),
+			err:           ErrExclusionRangeBeyondLog,
+		},
+		{
+			name:          "range beyond the log",
+			appID:         neverCommitted,
+			lowerMMRIndex: 0,
+			upperMMRIndex: mmr.MMRIndex(12),
+			err:           ErrExclusionRangeBeyondLog,
+		},
+		{
+			name:          "inverted range",
+			appID:         neverCommitted,
+			lowerMMRIndex: 3,
+			upperMMRIndex: 0,
+			err:           ErrExclusionRangeInvalid,
+		},
+		{
+			name:          "no leaves in the range",
+			appID:         neverCommitted,
+			lowerMMRIndex: 2,
+			upperMMRIndex: 2,
+			err:           ErrExclusionRangeNoLeaves,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			indexExclusion, err := AttestIndexExclusion(
+				context.Background(), localLog.Reader(), test.appID, localLog.logID,
+				test.lowerMMRIndex, test.upperMMRIndex)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Len(t, indexExclusion.Leaves, test.leafCount)
+
+			err = VerifyIndexExclusion(
+				indexExclusion, test.appID, localLog.logID, test.lowerMMRIndex, test.upperMMRIndex, keyStore)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// TestVerifyIndexExclusion tests that index exclusions fail to verify for indexed app entries,
+// for index exclusions that do not cover their whole range, or the range asked about, and for
+// index exclusions not bound to a trusted seal.
+func TestVerifyIndexExclusion(t *testing.T) {
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	appEntries := localLog.AppendEntries(3)
+	localLog.Seal()
+
+	keyStore := TrustedKeys{localLog.KeyID(): &localLog.signingKey.PublicKey}
+
+	neverCommitted := fmt.Sprintf("events/%s", uuid.NewString())
+
+	indexExclusion, err := AttestIndexExclusion(
+		context.Background(), localLog.Reader(), neverCommitted, localLog.logID, 0, mmr.MMRIndex(2))
+	require.NoError(t, err)
+
+	incompleteExclusion := *indexExclusion
+	incompleteExclusion.Leaves = indexExclusion.Leaves[1:]
+
+	// a made up leaf is not included under the peaks of the seal
+	madeUpExclusion := *indexExclusion
+	madeUpExclusion.Leaves = slices.Clone(indexExclusion.Leaves)
+	madeUpExclusion.Leaves[1].MMREntry = sha256.New().Sum(nil)
+
+	// an index exclusion of a single leaf, away from the range asked about
+	narrowExclusion, err := AttestIndexExclusion(
+		context.Background(), localLog.Reader(), neverCommitted, localLog.logID, mmr.MMRIndex(2), mmr.MMRIndex(2))
+	require.NoError(t, err)
+
+	// a range beyond the seal
+	beyondSealExclusion := *indexExclusion
+	beyondSealExclusion.LastLeafIndex++
+
+	otherLogID, err := uuid.New().MarshalBinary()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		indexExclusion *IndexExclusion
+		appID          string
+		logID          []byte
+		lowerMMRIndex  uint64
+		upperMMRIndex  uint64
+		keyStore       KeyStore
+		err            error
+	}{
+		{
+			name:           "positive",
+			indexExclusion: indexExclusion,
+			appID:          neverCommitted,
+			logID:          localLog.logID,
+			lowerMMRIndex:  0,
+			upperMMRIndex:  mmr.MMRIndex(2),
+			keyStore:       keyStore,
+		},
+		{
+			name:           "included app entry",
+			indexExclusion: indexExclusion,
+			appID:          appEntries[1].AppID(),
+			logID:          localLog.logID,
+			lowerMMRIndex:  0,
+			upperMMRIndex:  mmr.MMRIndex(2),
+			keyStore:       keyStore,
+			err:            ErrAppEntryIndexed,
+		},
+		{
+			name:           "incomplete index exclusion",
+			indexExclusion: &incompleteExclusion,
+			appID:          neverCommitted,
+			logID:          localLog.logID,
+			lowerMMRIndex:  0,
+			upperMMRIndex:  mmr.MMRIndex(2),
+			keyStore:       keyStore,
+			err:            ErrIndexExclusionIncomplete,
+		},
+		{
+			name:           "made up leaf",
+			indexExclusion: &madeUpExclusion,
+			appID:          neverCommitted,
+			logID:          localLog.logID,
+			lowerMMRIndex:  0,
+			upperMMRIndex:  mmr.MMRIndex(2),
+			keyStore:       keyStore,
+			err:            ErrIndexExclusionLeaf,
+		},
+		{
+			name:           "range beyond the seal",
+			indexExclusion: &beyondSealExclusion,
+			appID:          neverCommitted,
+			logID:          localLog.logID,
+			lowerMMRIndex:  0,
+			upperMMRIndex:  mmr.MMRIndex(2),
+			keyStore:       keyStore,
+			err:            ErrExclusionRangeBeyondLog,
+		},
+		{
+			name:           "range not covered by the index exclusion",
+			indexExclusion: narrowExclusion,
+			appID:          neverCommitted,
+			logID:          localLog.logID,
+			lowerMMRIndex:  0,
+			upperMMRIndex:  mmr.MMRIndex(2),
+			keyStore:       keyStore,
+			err:            ErrExclusionRangeNotCovered,
+		},
+		{
+			name:           "range asked about beyond the index exclusion",
+			indexExclusion: indexExclusion,
+			appID:          neverCommitted,
+			logID:          localLog.logID,
+			lowerMMRIndex:  0,
+			upperMMRIndex:  mmr.MMRIndex(3),
+			keyStore:       keyStore,
+			err:            ErrExclusionRangeNotCovered,
+		},
+		{
+			name:           "untrusted seal",
+			indexExclusion: indexExclusion,
+			appID:          neverCommitted,
+			logID:          localLog.logID,
+			lowerMMRIndex:  0,
+			upperMMRIndex:  mmr.MMRIndex(2),
+			keyStore:       TrustedKeys{},
+			err:            ErrUntrustedSealKey,
+		},
+		{
+			name:           "other log",
+			indexExclusion: indexExclusion,
+			appID:          neverCommitted,
+			logID:          otherLogID,
+			lowerMMRIndex:  0,
+			upperMMRIndex:  mmr.MMRIndex(2),
+			keyStore:       keyStore,
+			err:            ErrIndexExclusionLogMismatch,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifyIndexExclusion(
+				test.indexExclusion, test.appID, test.logID, test.lowerMMRIndex, test.upperMMRIndex, test.keyStore)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
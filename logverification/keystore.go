@@ -0,0 +1,376 @@
+package logverification
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+)
+
+/**
+ * Key Store holds the public keys trusted to sign seals.
+ *
+ * Sealing keys rotate, so each key has a window it is valid for. A seal is only
+ *  trusted if the key it names was valid at the time the seal was made.
+ *
+ * A key store can be loaded from a local file, in one of the following formats:
+ *
+ * JSON, a list of keys, each given either as a JWK or as a PEM encoded public key:
+ *
+ *   {
+ *     "keys": [
+ *       {
+ *         "kid": "location:key/version1",
+ *         "not_before": "2024-01-01T00:00:00Z",
+ *         "not_after": "2025-01-01T00:00:00Z",
+ *         "pem": "-----BEGIN PUBLIC KEY-----\n...\n-----END PUBLIC KEY-----\n"
+ *       },
+ *       {
+ *         "kid": "location:key/version2",
+ *         "not_before": "2025-01-01T00:00:00Z",
+ *         "kty": "EC", "crv": "P-256", "x": "...", "y": "..."
+ *       }
+ *     ]
+ *   }
+ *
+ *   A JWK Set is a valid key store file, with every key valid for all time.
+ *
+ * PEM, one or more public keys, each with its kid and optional window in the PEM headers:
+ *
+ *   -----BEGIN PUBLIC KEY-----
+ *   Kid: location:key/version1
+ *   Not-Before: 2024-01-01T00:00:00Z
+ *   Not-After: 2025-01-01T00:00:00Z
+ *
+ *   ...
+ *   -----END PUBLIC KEY-----
+ *
+ * Window times are RFC 3339, an omitted time leaves that side of the window open.
+ */
+
+const (
+	pemHeaderKid       = "Kid"
+	pemHeaderNotBefore = "Not-Before"
+	pemHeaderNotAfter  = "Not-After"
+
+	pemTypePublicKey = "PUBLIC KEY"
+
+	jwkKeyTypeEC = "EC"
+)
+
+var (
+	ErrUntrustedSealKey     = errors.New("the seal is not signed with a trusted key")
+	ErrSealKeyNotValid      = errors.New("the seal was not made within the validity window of its key")
+	ErrKeyStoreFileFormat   = errors.New("the key store file is not a recognised format")
+	ErrKeyStoreKeyIDMissing = errors.New("the key store key has no key id (kid)")
+	ErrUnsupportedKeyType   = errors.New("the key store key type is not supported")
+)
+
+// KeyStore gets the public keys trusted to sign seals.
+type KeyStore interface {
+
+	// PublicKey gets the public key with the given key id (kid),
+	//  if it is trusted to have signed a seal at the given time.
+	PublicKey(kid string, signedAt time.Time) (crypto.PublicKey, error)
+}
+
+// TrustedKeys are the public keys trusted to sign seals, by key id (kid),
+// for all time.
+type TrustedKeys map[string]crypto.PublicKey
+
+// PublicKey gets the trusted public key with the given key id (kid).
+func (tk TrustedKeys) PublicKey(kid string, signedAt time.Time) (crypto.PublicKey, error) {
+
+	publicKey, ok := tk[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %s", ErrUntrustedSealKey, kid)
+	}
+
+	return publicKey, nil
+}
+
+// TrustedKey is a public key trusted to sign seals within a validity window.
+type TrustedKey struct {
+	KeyID     string
+	PublicKey crypto.PublicKey
+
+	// NotBefore is the earliest time the key is valid, the zero time means no lower bound.
+	NotBefore time.Time
+
+	// NotAfter is the latest time the key is valid, the zero time means no upper bound.
+	NotAfter time.Time
+}
+
+// ValidAt returns true if the key is valid at the given time.
+func (k *TrustedKey) ValidAt(at time.Time) bool {
+
+	if !k.NotBefore.IsZero() && at.Before(k.NotBefore) {
+		return false
+	}
+
+	if !k.NotAfter.IsZero() && at.After(k.NotAfter) {
+		return false
+	}
+
+	return true
+}
+
+// TrustedKeyStore is a KeyStore of keys with validity windows.
+//
+// A key id can have more than one key, e.g. if a key is re-issued
+// under the same kid, each with its own window.
+type TrustedKeyStore struct {
+	keys map[string][]TrustedKey
+}
+
+// NewTrustedKeyStore creates a new key store with the given keys.
+func NewTrustedKeyStore(keys ...TrustedKey) *TrustedKeyStore {
+
+	keyStore := &TrustedKeyStore{
+		keys: map[string][]TrustedKey{},
+	}
+
+	for _, key := range keys {
+		keyStore.Add(key)
+	}
+
+	return keyStore
+}
+
+// Add adds the given key to the key store.
+func (ks *TrustedKeyStore) Add(key TrustedKey) {
+	ks.keys[key.KeyID] = append(ks.keys[key.KeyID], key)
+}
+
+// PublicKey gets the public key with the given key id (kid),
+// that is valid at the given time.
+func (ks *TrustedKeyStore) PublicKey(kid string, signedAt time.Time) (crypto.PublicKey, error) {
+
+	keys, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %s", ErrUntrustedSealKey, kid)
+	}
+
+	for _, key := range keys {
+		if key.ValidAt(signedAt) {
+			return key.PublicKey, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: kid %s, signed at %s", ErrSealKeyNotValid, kid, signedAt.Format(time.RFC3339))
+}
+
+// keyStoreFile is the JSON key store file format.
+type keyStoreFile struct {
+	Keys []keyStoreFileKey `json:"keys"`
+}
+
+// keyStoreFileKey is a key in the JSON key store file format.
+//
+// The public key is given by either the pem field or the JWK fields.
+type keyStoreFileKey struct {
+	KeyID     string     `json:"kid"`
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	NotAfter  *time.Time `json:"not_after,omitempty"`
+
+	PEM string `json:"pem,omitempty"`
+
+	KeyType string `json:"kty,omitempty"`
+	Curve   string `json:"crv,omitempty"`
+	X       string `json:"x,omitempty"`
+	Y       string `json:"y,omitempty"`
+}
+
+// LoadKeyStore loads a key store from the given local JSON, JWK Set or PEM file.
+func LoadKeyStore(filePath string) (*TrustedKeyStore, error) {
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseKeyStore(data)
+}
+
+// ParseKeyStore parses a key store from the contents of a JSON, JWK Set or PEM file.
+func ParseKeyStore(data []byte) (*TrustedKeyStore, error) {
+
+	data = bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(data, []byte("-----BEGIN")) {
+		return parsePEMKeyStore(data)
+	}
+
+	if bytes.HasPrefix(data, []byte("{")) {
+		return parseJSONKeyStore(data)
+	}
+
+	return nil, ErrKeyStoreFileFormat
+}
+
+// parseJSONKeyStore parses a key store from the contents of a JSON or JWK Set file.
+func parseJSONKeyStore(data []byte) (*TrustedKeyStore, error) {
+
+	file := keyStoreFile{}
+	err := json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrKeyStoreFileFormat, err)
+	}
+
+	keyStore := NewTrustedKeyStore()
+
+	for _, fileKey := range file.Keys {
+
+		if fileKey.KeyID == "" {
+			return nil, ErrKeyStoreKeyIDMissing
+		}
+
+		key := TrustedKey{
+			KeyID: fileKey.KeyID,
+		}
+
+		if fileKey.NotBefore != nil {
+			key.NotBefore = *fileKey.NotBefore
+		}
+
+		if fileKey.NotAfter != nil {
+			key.NotAfter = *fileKey.NotAfter
+		}
+
+		if fileKey.PEM != "" {
+			key.PublicKey, err = parsePEMPublicKey([]byte(fileKey.PEM))
+		} else {
+			key.PublicKey, err = parseJWKPublicKey(fileKey)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse key with kid %s: %w", fileKey.KeyID, err)
+		}
+
+		keyStore.Add(key)
+	}
+
+	return keyStore, nil
+}
+
+// parsePEMKeyStore parses a key store from the contents of a PEM file.
+func parsePEMKeyStore(data []byte) (*TrustedKeyStore, error) {
+
+	keyStore := NewTrustedKeyStore()
+
+	for {
+
+		block, rest := pem.Decode(data)
+		if block == nil {
+			break
+		}
+		data = rest
+
+		if block.Type != pemTypePublicKey {
+			return nil, fmt.Errorf("%w: unexpected pem block type %s", ErrKeyStoreFileFormat, block.Type)
+		}
+
+		key := TrustedKey{
+			KeyID: block.Headers[pemHeaderKid],
+		}
+
+		if key.KeyID == "" {
+			return nil, ErrKeyStoreKeyIDMissing
+		}
+
+		var err error
+		key.NotBefore, err = parseWindowTime(block.Headers[pemHeaderNotBefore])
+		if err != nil {
+			return nil, err
+		}
+
+		key.NotAfter, err = parseWindowTime(block.Headers[pemHeaderNotAfter])
+		if err != nil {
+			return nil, err
+		}
+
+		key.PublicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse key with kid %s: %w", key.KeyID, err)
+		}
+
+		keyStore.Add(key)
+	}
+
+	return keyStore, nil
+}
+
+// parseWindowTime parses an RFC 3339 validity window time, an empty string is the zero time.
+func parseWindowTime(value string) (time.Time, error) {
+
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// parsePEMPublicKey parses a single PEM encoded public key.
+func parsePEMPublicKey(data []byte) (crypto.PublicKey, error) {
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemTypePublicKey {
+		return nil, fmt.Errorf("%w: expected a pem encoded public key", ErrKeyStoreFileFormat)
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// parseJWKPublicKey parses the public key from the JWK fields of the given key.
+//
+// Only EC keys are supported, as seals are signed with EC keys.
+func parseJWKPublicKey(fileKey keyStoreFileKey) (crypto.PublicKey, error) {
+
+	if fileKey.KeyType != jwkKeyTypeEC {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedKeyType, fileKey.KeyType)
+	}
+
+	var curve elliptic.Curve
+	switch fileKey.Curve {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("%w: curve %q", ErrUnsupportedKeyType, fileKey.Curve)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(fileKey.X)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := base64.RawURLEncoding.DecodeString(fileKey.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+
+	// checks the point is on the curve
+	_, err = publicKey.ECDH()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedKeyType, err)
+	}
+
+	return publicKey, nil
+}
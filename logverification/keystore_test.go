@@ -0,0 +1,218 @@
+package logverification
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/datatrails/go-datatrails-common/logger"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPEMPublicKey pem encodes the given public key, with the given pem headers.
+func testPEMPublicKey(t *testing.T, publicKey *ecdsa.PublicKey, headers map[string]string) string {
+
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Headers: headers, Bytes: der}))
+}
+
+// TestVerifySignedLogState_KeyStore tests that seals are only verified with keys
+// that were valid at the time of the seal.
+func TestVerifySignedLogState_KeyStore(t *testing.T) {
+	logger.New("TestVerifySignedLogState_KeyStore")
+	defer logger.OnExit()
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	localLog.AppendEntries(3)
+	localLog.Seal()
+
+	signedState, err := SignedLogState(
+		context.Background(), localLog.Reader(), sha256.New(), localLog.codec, localLog.tenantID, 0)
+	require.NoError(t, err)
+
+	logState, err := LogState(signedState, localLog.codec)
+	require.NoError(t, err)
+
+	sealedAt, err := SealTime(logState)
+	require.NoError(t, err)
+
+	kid := localLog.KeyID()
+	publicKey := &localLog.signingKey.PublicKey
+	oldKey := massifs.TestGenerateECKey(t, elliptic.P256())
+
+	tests := []struct {
+		name string
+		keys []TrustedKey
+		err  error
+	}{
+		{
+			name: "within the window",
+			keys: []TrustedKey{
+				{KeyID: kid, PublicKey: publicKey, NotBefore: sealedAt.Add(-time.Hour), NotAfter: sealedAt.Add(time.Hour)},
+			},
+			err: nil,
+		},
+		{
+			name: "open window",
+			keys: []TrustedKey{
+				{KeyID: kid, PublicKey: publicKey},
+			},
+			err: nil,
+		},
+		{
+			name: "key expired before the seal",
+			keys: []TrustedKey{
+				{KeyID: kid, PublicKey: publicKey, NotAfter: sealedAt.Add(-time.Hour)},
+			},
+			err: ErrSealKeyNotValid,
+		},
+		{
+			name: "key not valid until after the seal",
+			keys: []TrustedKey{
+				{KeyID: kid, PublicKey: publicKey, NotBefore: sealedAt.Add(time.Hour)},
+			},
+			err: ErrSealKeyNotValid,
+		},
+		{
+			name: "rotated key under the same kid",
+			keys: []TrustedKey{
+				{KeyID: kid, PublicKey: &oldKey.PublicKey, NotAfter: sealedAt.Add(-time.Hour)},
+				{KeyID: kid, PublicKey: publicKey, NotBefore: sealedAt.Add(-time.Hour)},
+			},
+			err: nil,
+		},
+		{
+			name: "unknown kid",
+			keys: []TrustedKey{
+				{KeyID: "location:otherkey/version1", PublicKey: publicKey},
+			},
+			err: ErrUntrustedSealKey,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := VerifySignedLogState(signedState, localLog.codec, NewTrustedKeyStore(test.keys...))
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+// TestLoadKeyStore tests that key stores load from JSON, JWK Set and PEM files.
+func TestLoadKeyStore(t *testing.T) {
+
+	key := massifs.TestGenerateECKey(t, elliptic.P256())
+
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	validAt := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	invalidAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	jsonPEM, err := json.Marshal(map[string]any{
+		"keys": []map[string]any{
+			{
+				"kid":        "location:key/version1",
+				"not_before": notBefore,
+				"not_after":  notAfter,
+				"pem":        testPEMPublicKey(t, &key.PublicKey, nil),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	key.PublicKey.X.FillBytes(x)
+	key.PublicKey.Y.FillBytes(y)
+
+	jsonJWK, err := json.Marshal(map[string]any{
+		"keys": []map[string]any{
+			{
+				"kid":        "location:key/version1",
+				"not_before": notBefore,
+				"not_after":  notAfter,
+				"kty":        "EC",
+				"crv":        "P-256",
+				"x":          base64.RawURLEncoding.EncodeToString(x),
+				"y":          base64.RawURLEncoding.EncodeToString(y),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	pemFile := testPEMPublicKey(t, &key.PublicKey, map[string]string{
+		"Kid":        "location:key/version1",
+		"Not-Before": notBefore.Format(time.RFC3339),
+		"Not-After":  notAfter.Format(time.RFC3339),
+	})
+
+	tests := []struct {
+		name     string
+		contents string
+		err      error
+	}{
+		{
+			name:     "json with pem key",
+			contents: string(jsonPEM),
+			err:      nil,
+		},
+		{
+			name:     "json with jwk key",
+			contents: string(jsonJWK),
+			err:      nil,
+		},
+		{
+			name:     "pem",
+			contents: pemFile,
+			err:      nil,
+		},
+		{
+			name:     "pem without a kid",
+			contents: testPEMPublicKey(t, &key.PublicKey, nil),
+			err:      ErrKeyStoreKeyIDMissing,
+		},
+		{
+			name:     "unsupported jwk key type",
+			contents: `{"keys": [{"kid": "location:key/version1", "kty": "OKP", "crv": "Ed25519", "x": "AA"}]}`,
+			err:      ErrUnsupportedKeyType,
+		},
+		{
+			name:     "unknown format",
+			contents: "not a key store",
+			err:      ErrKeyStoreFileFormat,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			filePath := filepath.Join(t.TempDir(), "keys")
+			err := os.WriteFile(filePath, []byte(test.contents), 0o600)
+			require.NoError(t, err)
+
+			keyStore, err := LoadKeyStore(filePath)
+			assert.ErrorIs(t, err, test.err)
+			if test.err != nil {
+				return
+			}
+
+			publicKey, err := keyStore.PublicKey("location:key/version1", validAt)
+			require.NoError(t, err)
+			assert.True(t, key.PublicKey.Equal(publicKey))
+
+			_, err = keyStore.PublicKey("location:key/version1", invalidAt)
+			assert.ErrorIs(t, err, ErrSealKeyNotValid)
+		})
+	}
+}
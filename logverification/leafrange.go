@@ -1,7 +1,6 @@
 package logverification
 
 import (
-	"github.com/datatrails/go-datatrails-logverification/logverification/app"
 	"github.com/datatrails/go-datatrails-merklelog/mmr"
 )
 
@@ -15,7 +14,7 @@ import (
 //	events, that have been sorted from lowest mmr index to highest mmr index.
 //
 // Returns the lower and upper bound of the leaf indexes for the leaf range.
-func LeafRange(sortedEvents []app.AppEntry) (uint64, uint64) {
+func LeafRange[E VerifiableAppEntry](sortedEvents []E) (uint64, uint64) {
 
 	lowerBoundMMRIndex := sortedEvents[0].MMRIndex()
 	lowerBoundLeafIndex := mmr.LeafCount(lowerBoundMMRIndex+1) - 1 // Note: LeafCount takes an mmrIndex here not a size
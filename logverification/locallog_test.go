@@ -0,0 +1,371 @@
+package logverification
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/datatrails/go-datatrails-common-api-gen/assets/v2/assets"
+	"github.com/datatrails/go-datatrails-common-api-gen/attribute/v2/attribute"
+	"github.com/datatrails/go-datatrails-common/cbor"
+	"github.com/datatrails/go-datatrails-common/cose"
+	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/datatrails/go-datatrails-merklelog/mmr"
+	"github.com/datatrails/go-datatrails-serialization/eventsv1"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+/**
+ * Defines a merklelog written to the local file system, only used in testing.
+ *
+ * The log is laid out in the same way as it is in blob storage, so that it can be
+ *  read with the FileSystemReader, without the need for azurite.
+ */
+
+const (
+	testLocalLogTenantUUID = "6a8b8b88-4bd6-4c0f-a0a1-2e3b4b1c3b5e"
+	testLocalLogTenantID   = "tenant/" + testLocalLogTenantUUID
+
+	// testLocalLogMassifHeight gives 4 leaves per massif
+	testLocalLogMassifHeight = uint8(3)
+
+	// testLocalLogAppDomain is a non zero app domain, so the log entries are log version 1 entries.
+	testLocalLogAppDomain = byte(1)
+)
+
+// testLocalLog is a merklelog for a single tenant, stored in a temporary directory.
+type testLocalLog struct {
+	t *testing.T
+
+	rootDir  string
+	tenantID string
+	logID    []byte
+
+	massifContext massifs.MassifContext
+
+	// appEntries are all the app entries committed to the log, in mmr index order.
+	appEntries []app.AppEntry
+
+	signingKey ecdsa.PrivateKey
+	codec      cbor.CBORCodec
+
+	nextIDTimestamp uint64
+}
+
+// newTestLocalLog creates an empty local log with the given massif height.
+func newTestLocalLog(t *testing.T, massifHeight uint8) *testLocalLog {
+
+	logUUID, err := uuid.Parse(testLocalLogTenantUUID)
+	require.NoError(t, err)
+
+	logID, err := logUUID.MarshalBinary()
+	require.NoError(t, err)
+
+	codec, err := massifs.NewRootSignerCodec()
+	require.NoError(t, err)
+
+	start := massifs.NewMassifStart(0, 1, massifHeight, 0, 0)
+
+	massifContext := massifs.MassifContext{
+		TenantIdentity: testLocalLogTenantID,
+		Start:          start,
+		LogBlobContext: massifs.LogBlobContext{
+			BlobPath: massifs.TenantMassifBlobPath(testLocalLogTenantID, 0),
+			Tags:     map[string]string{},
+		},
+	}
+
+	data, err := start.MarshalBinary()
+	require.NoError(t, err)
+
+	massifContext.Data = append(data, massifContext.InitIndexData()...)
+
+	return &testLocalLog{
+		t:               t,
+		rootDir:         t.TempDir(),
+		tenantID:        testLocalLogTenantID,
+		logID:           logID,
+		massifContext:   massifContext,
+		signingKey:      massifs.TestGenerateECKey(t, elliptic.P256()),
+		codec:           codec,
+		nextIDTimestamp: 0x018d3b472e221464,
+	}
+}
+
+// Reader returns a file system reader for the local log.
+func (l *testLocalLog) Reader() *FileSystemReader {
+	reader, err := NewFileSystemReader(l.rootDir)
+	require.NoError(l.t, err)
+
+	return reader
+}
+
+// AppendEntries appends count new app entries to the log, writing every massif
+// that changes to the local file system.
+//
+// Returns the newly appended app entries.
+func (l *testLocalLog) AppendEntries(count int) []app.AppEntry {
+
+	appended := []app.AppEntry{}
+
+	for range count {
+
+		idTimestamp := l.nextIDTimestamp
+		l.nextIDTimestamp++
+
+		appID := fmt.Sprintf("events/%s", uuid.NewString())
+		serializedBytes := []byte(fmt.Sprintf(`{"identity":"%s"}`, appID))
+
+		extraBytes, leafValue := logVersion1Leaf(idTimestamp, serializedBytes)
+
+		mmrIndex := l.addLeaf(idTimestamp, extraBytes, appID, leafValue)
+
+		appEntry := app.NewAppEntry(appID, l.logID, app.NewMMREntryFields(0, serializedBytes), mmrIndex)
+		appended = append(appended, *appEntry)
+	}
+
+	l.writeMassif()
+
+	l.appEntries = append(l.appEntries, appended...)
+
+	return appended
+}
+
+// AppendEvents appends count new assetsv2 events to the log, as log version 0 entries,
+// writing every massif that changes to the local file system.
+//
+// Returns the event json of the newly appended events, as returned by the events API.
+func (l *testLocalLog) AppendEvents(count int) [][]byte {
+
+	appended := [][]byte{}
+
+	for range count {
+
+		idTimestamp := l.nextIDTimestamp
+		l.nextIDTimestamp++
+
+		assetUUID := uuid.NewString()
+
+		event := &assets.EventResponse{
+			Identity:       assets.EventIdentityFromUuid(assetUUID, uuid.NewString()),
+			AssetIdentity:  assets.AssetIdentityFromUuid(assetUUID),
+			TenantIdentity: l.tenantID,
+			Operation:      "Record",
+			Behaviour:      "RecordEvidence",
+			EventAttributes: map[string]*attribute.Attribute{
+				"event-attribute-0": {Value: &attribute.Attribute_StrVal{StrVal: uuid.NewString()}},
+			},
+			TimestampDeclared: timestamppb.New(time.UnixMilli(1700000000000)),
+			TimestampAccepted: timestamppb.New(time.UnixMilli(1700000000000)),
+			MerklelogEntry: &assets.MerkleLogEntry{
+				Commit: &assets.MerkleLogCommit{
+					// the next leaf is always added at the current size of the log
+					Index:       l.massifContext.RangeCount(),
+					Idtimestamp: massifs.IDTimestampToHex(idTimestamp, 1),
+				},
+			},
+		}
+
+		eventJson, err := assets.NewFlatMarshalerForEvents().Marshal(event)
+		require.NoError(l.t, err)
+
+		idTimestampBytes := make([]byte, app.IDTimestapSizeBytes)
+		binary.BigEndian.PutUint64(idTimestampBytes, idTimestamp)
+
+		leafValue, err := app.NewLogVersion0Hasher().HashEvent(eventJson, idTimestampBytes)
+		require.NoError(l.t, err)
+
+		// log version 0 entries have no app domain in the extra bytes
+		extraBytes := make([]byte, app.ExtraBytesSize)
+
+		mmrIndex := l.addLeaf(idTimestamp, extraBytes, event.Identity, leafValue)
+		require.Equal(l.t, event.MerklelogEntry.Commit.Index, mmrIndex)
+
+		appended = append(appended, eventJson)
+	}
+
+	l.writeMassif()
+
+	return appended
+}
+
+// AppendEventsV1 appends count new eventsv1 events to the log, as log version 1 entries,
+// writing every massif that changes to the local file system.
+//
+// Returns the event json of the newly appended events, as returned by the events API.
+func (l *testLocalLog) AppendEventsV1(count int) [][]byte {
+
+	appended := [][]byte{}
+
+	for range count {
+
+		idTimestamp := l.nextIDTimestamp
+		l.nextIDTimestamp++
+
+		appID := fmt.Sprintf("events/%s", uuid.NewString())
+
+		// the next leaf is always added at the current size of the log
+		eventJson := []byte(fmt.Sprintf(`{
+			"identity": "%s",
+			"attributes": {"event-attribute-0": "%s"},
+			"trails": [],
+			"origin_tenant": "%s",
+			"created_by": "%s",
+			"created_at": 1700000000000,
+			"confirmation_status": "CONFIRMED",
+			"merklelog_commit": {"index": "%d", "idtimestamp": "%s"}
+		}`,
+			appID,
+			uuid.NewString(),
+			l.tenantID,
+			uuid.NewString(),
+			l.massifContext.RangeCount(),
+			massifs.IDTimestampToHex(idTimestamp, 1),
+		))
+
+		serializedBytes, err := eventsv1.SerializeEventFromJson(eventJson)
+		require.NoError(l.t, err)
+
+		extraBytes, leafValue := logVersion1Leaf(idTimestamp, serializedBytes)
+
+		l.addLeaf(idTimestamp, extraBytes, appID, leafValue)
+
+		appended = append(appended, eventJson)
+	}
+
+	l.writeMassif()
+
+	return appended
+}
+
+// logVersion1Leaf gets the extra bytes and the leaf value of a log version 1 entry,
+// in the local log's app domain, with the given serialized bytes.
+func logVersion1Leaf(idTimestamp uint64, serializedBytes []byte) ([]byte, []byte) {
+
+	extraBytes := make([]byte, app.ExtraBytesSize)
+	extraBytes[0] = testLocalLogAppDomain
+
+	idTimestampBytes := make([]byte, app.IDTimestapSizeBytes)
+	binary.BigEndian.PutUint64(idTimestampBytes, idTimestamp)
+
+	// H( Domain | MMR Salt | Serialized Bytes)
+	leafHasher := sha256.New()
+	leafHasher.Write([]byte{0})
+	leafHasher.Write(extraBytes)
+	leafHasher.Write(idTimestampBytes)
+	leafHasher.Write(serializedBytes)
+
+	return extraBytes, leafHasher.Sum(nil)
+}
+
+// addLeaf adds the given leaf value to the log, starting a new massif if the
+// current massif is full.
+//
+// Returns the mmr index of the leaf.
+func (l *testLocalLog) addLeaf(idTimestamp uint64, extraBytes []byte, appID string, leafValue []byte) uint64 {
+
+	mmrIndex := l.massifContext.RangeCount()
+
+	_, err := l.massifContext.AddHashedLeaf(
+		sha256.New(), idTimestamp, extraBytes, l.logID, []byte(appID), leafValue)
+	if errors.Is(err, massifs.ErrMassifFull) {
+
+		l.writeMassif()
+
+		err = l.massifContext.StartNextMassif()
+		require.NoError(l.t, err)
+
+		l.massifContext.BlobPath = massifs.TenantMassifBlobPath(
+			l.tenantID, uint64(l.massifContext.Start.MassifIndex))
+
+		mmrIndex = l.massifContext.RangeCount()
+
+		_, err = l.massifContext.AddHashedLeaf(
+			sha256.New(), idTimestamp, extraBytes, l.logID, []byte(appID), leafValue)
+	}
+	require.NoError(l.t, err)
+
+	return mmrIndex
+}
+
+// Seal signs the current state of the log, and writes the seal for the
+// current head massif to the local file system.
+//
+// Returns the signed state of the log, including the peaks.
+func (l *testLocalLog) Seal() *massifs.MMRState {
+
+	mmrSize := l.massifContext.RangeCount()
+
+	// read the head massif back, so that the peaks in the ancestor peak stack are available
+	massifContext := l.Massif(uint64(l.massifContext.Start.MassifIndex))
+
+	peaks, err := mmr.PeakHashes(massifContext, mmrSize-1)
+	require.NoError(l.t, err)
+
+	state := massifs.MMRState{
+		Version:         int(massifs.MMRStateVersionCurrent),
+		MMRSize:         mmrSize,
+		Peaks:           peaks,
+		IDTimestamp:     l.nextIDTimestamp - 1,
+		CommitmentEpoch: 1,
+	}
+
+	signer := massifs.NewRootSigner("test-issuer", l.codec)
+	coseSigner := cose.NewTestCoseSigner(l.t, l.signingKey)
+
+	publicKey, err := coseSigner.LatestPublicKey()
+	require.NoError(l.t, err)
+
+	sealBytes, err := signer.Sign1(coseSigner, coseSigner.KeyIdentifier(), publicKey, "test-subject", state, nil)
+	require.NoError(l.t, err)
+
+	l.writeBlob(massifs.TenantMassifSignedRootPath(l.tenantID, l.massifContext.Start.MassifIndex), sealBytes)
+
+	return &state
+}
+
+// KeyID returns the key id (kid) of the key the log is sealed with.
+func (l *testLocalLog) KeyID() string {
+	return cose.NewTestCoseSigner(l.t, l.signingKey).KeyIdentifier()
+}
+
+// MassifGetter returns a massif reader for the local log.
+func (l *testLocalLog) MassifGetter() *massifs.MassifReader {
+	massifReader := massifs.NewMassifReader(nil, l.Reader())
+	return &massifReader
+}
+
+// Massif reads the massif with the given index back from the local file system.
+func (l *testLocalLog) Massif(massifIndex uint64) *massifs.MassifContext {
+	massifContext, err := l.MassifGetter().GetMassif(context.Background(), l.tenantID, massifIndex)
+	require.NoError(l.t, err)
+
+	return &massifContext
+}
+
+// writeMassif writes the current massif to the local file system.
+func (l *testLocalLog) writeMassif() {
+	l.writeBlob(l.massifContext.BlobPath, l.massifContext.Data)
+}
+
+// writeBlob writes the given data to the local file system at the given blob path.
+func (l *testLocalLog) writeBlob(blobPath string, data []byte) {
+	filePath := filepath.Join(l.rootDir, filepath.FromSlash(blobPath))
+
+	err := os.MkdirAll(filepath.Dir(filePath), 0o755)
+	require.NoError(l.t, err)
+
+	err = os.WriteFile(filePath, data, 0o600)
+	require.NoError(l.t, err)
+}
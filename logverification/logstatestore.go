@@ -0,0 +1,395 @@
+package logverification
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/datatrails/go-datatrails-common/azblob"
+	"github.com/datatrails/go-datatrails-common/cbor"
+	"github.com/datatrails/go-datatrails-common/cose"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+)
+
+/**
+ * Log State Store keeps the trusted states of tenants' logs, so that later states can be
+ *  verified as consistent with them.
+ *
+ * A new log state is only recorded by a store if it is proven consistent with the latest trusted
+ *  log state for the tenant. The first log state recorded for a tenant is trusted on first use.
+ *
+ * The file system log state store keeps the history of trusted log states of each tenant
+ *  in a local directory, one file per log state, e.g.
+ *
+ *   <root>/tenant/<uuid>/0000000000000013.cbor
+ *
+ * Where the file name is the mmr size of the log state, in hex.
+ */
+
+const (
+	logStateFileExt = ".cbor"
+)
+
+var (
+	ErrLogStateNotFound     = errors.New("there is no trusted log state for the tenant")
+	ErrLogStateRollback     = errors.New("the log state is smaller than the latest trusted log state")
+	ErrLogStateInconsistent = errors.New("the log state is not consistent with the latest trusted log state")
+	ErrLogStateProofMissing = errors.New("a consistency proof from the latest trusted log state is required")
+	ErrLogStateTenantPath   = errors.New("the tenant id does not resolve to a location under the log state store root")
+)
+
+// TrustedLogState is a verified state of a log, and the seal it was verified from.
+type TrustedLogState struct {
+	LogState *massifs.MMRState `cbor:"1,keyasint"`
+
+	// Seal is the COSE Sign1 seal of the log state, serialized to CBOR, with the peaks
+	// recomputed from the log as its payload, as returned by SignedLogState.
+	Seal []byte `cbor:"2,keyasint"`
+}
+
+// SignedState decodes the seal of the trusted log state.
+func (s *TrustedLogState) SignedState() (*cose.CoseSign1Message, error) {
+	return cose.NewCoseSign1MessageFromCBOR(s.Seal, cose.WithDecOptions(massifs.CheckpointDecOptions()))
+}
+
+// LogStateStore keeps the trusted log states of tenants' logs.
+type LogStateStore interface {
+
+	// Latest gets the latest trusted log state for the given tenant.
+	//
+	// Returns ErrLogStateNotFound if there is no trusted log state for the tenant.
+	Latest(tenantID string) (*TrustedLogState, error)
+
+	// Record records the given verified log state as the latest trusted log state for the
+	// given tenant, if it is proven consistent with the current latest trusted log state by
+	// the given consistency proof.
+	//
+	// If there is no trusted log state for the tenant, the given log state is trusted on first use,
+	// and the proof is not needed. If the given log state is the same size as the latest trusted
+	// log state, its peaks must be the same, and the proof is not needed.
+	//
+	// NOTE: the log state's signature is not verified, it is expected that the signature
+	// verification is done before the log state is recorded, e.g. by VerifySignedLogState.
+	Record(tenantID string, logState *TrustedLogState, proof *ConsistencyProof) error
+}
+
+// checkLogState checks the given log state is proven consistent with the given latest trusted
+// log state by the given consistency proof, so can be recorded as the latest trusted log state.
+//
+// If there is no latest trusted log state, the given log state is trusted on first use.
+func checkLogState(latest *TrustedLogState, logState *TrustedLogState, proof *ConsistencyProof) error {
+
+	if latest == nil {
+		return nil
+	}
+
+	latestSize := latest.LogState.MMRSize
+	newSize := logState.LogState.MMRSize
+
+	if newSize < latestSize {
+		return fmt.Errorf("%w: mmr size %d, latest trusted mmr size %d", ErrLogStateRollback, newSize, latestSize)
+	}
+
+	// the log has not grown, so the log state can only be consistent if it is the same
+	if newSize == latestSize {
+
+		if !slices.EqualFunc(latest.LogState.Peaks, logState.LogState.Peaks, bytes.Equal) {
+			return fmt.Errorf("%w: the peaks differ at mmr size %d", ErrLogStateInconsistent, newSize)
+		}
+
+		return nil
+	}
+
+	if proof == nil {
+		return ErrLogStateProofMissing
+	}
+
+	if proof.MMRSizeA != latestSize || proof.MMRSizeB != newSize {
+		return fmt.Errorf("%w: the proof is from mmr size %d to %d, expected from %d to %d",
+			ErrLogStateInconsistent, proof.MMRSizeA, proof.MMRSizeB, latestSize, newSize)
+	}
+
+	verified, err := VerifyConsistencyProof(proof, latest.LogState.Peaks, logState.LogState.Peaks)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLogStateInconsistent, err)
+	}
+
+	if !verified {
+		return ErrLogStateInconsistent
+	}
+
+	return nil
+}
+
+// TrustLogState gets the signed state of the log for the massif at the given massif index,
+// verifies it against the given key store, and records it in the given store if it is
+// consistent with the latest trusted log state for the tenant.
+//
+// The consistency proof from the latest trusted log state is built from the log.
+//
+// Returns the newly trusted log state. If the log state is verified, but not recorded because it
+// is not consistent with the latest trusted log state, it is returned along with the error.
+//
+// The options argument can be the following:
+//
+//	WithVerifyMassifHeight - the massif height of the merklelog, instead of
+//	                         discovering it from the log.
+func TrustLogState(
+	ctx context.Context,
+	store LogStateStore,
+	reader azblob.Reader,
+	codec cbor.CBORCodec,
+	keyStore KeyStore,
+	tenantID string,
+	massifIndex uint64,
+	options ...VerifyOption,
+) (*TrustedLogState, error) {
+
+	signedState, err := SignedLogState(ctx, reader, sha256.New(), codec, tenantID, massifIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	logState, err := VerifySignedLogState(signedState, codec, keyStore)
+	if err != nil {
+		return nil, err
+	}
+
+	seal, err := signedState.MarshalCBOR()
+	if err != nil {
+		return nil, fmt.Errorf("TrustLogState failed: unable to cbor encode the seal: %w", err)
+	}
+
+	trustedState := &TrustedLogState{
+		LogState: logState,
+		Seal:     seal,
+	}
+
+	var proof *ConsistencyProof
+
+	latest, err := store.Latest(tenantID)
+	if err != nil && !errors.Is(err, ErrLogStateNotFound) {
+		return nil, err
+	}
+
+	// only a log that has grown since the latest trusted log state needs a proof
+	if latest != nil && logState.MMRSize > latest.LogState.MMRSize {
+
+		proof, err = NewConsistencyProof(
+			ctx, reader, tenantID, latest.LogState.MMRSize, logState.MMRSize, options...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = store.Record(tenantID, trustedState, proof)
+	if err != nil {
+		return trustedState, err
+	}
+
+	return trustedState, nil
+}
+
+// FileSystemLogStateStore is a LogStateStore that keeps the history of trusted log states
+// in a local directory.
+type FileSystemLogStateStore struct {
+
+	// rootDir is the directory that tenant log states are kept under.
+	rootDir string
+
+	codec cbor.CBORCodec
+
+	// recordLock ensures the latest trusted log state does not change
+	//  between its consistency check and the write of the next.
+	recordLock sync.Mutex
+}
+
+// NewFileSystemLogStateStore creates a new file system log state store, keeping log states
+// under the given root directory, which is created if it does not exist.
+func NewFileSystemLogStateStore(rootDir string) (*FileSystemLogStateStore, error) {
+
+	err := os.MkdirAll(rootDir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := massifs.NewRootSignerCodec()
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSystemLogStateStore{
+		rootDir: rootDir,
+		codec:   codec,
+	}, nil
+}
+
+// Latest gets the latest trusted log state for the given tenant.
+func (s *FileSystemLogStateStore) Latest(tenantID string) (*TrustedLogState, error) {
+
+	filePaths, err := s.logStateFiles(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(filePaths) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrLogStateNotFound, tenantID)
+	}
+
+	return s.readLogState(filePaths[len(filePaths)-1])
+}
+
+// History gets every trusted log state for the given tenant, from the first to the latest.
+func (s *FileSystemLogStateStore) History(tenantID string) ([]*TrustedLogState, error) {
+
+	filePaths, err := s.logStateFiles(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := []*TrustedLogState{}
+	for _, filePath := range filePaths {
+
+		logState, err := s.readLogState(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		history = append(history, logState)
+	}
+
+	return history, nil
+}
+
+// Record records the given verified log state as the latest trusted log state for the given
+// tenant, if it is proven consistent with the current latest trusted log state by the given
+// consistency proof.
+//
+// A log state of the same size as one already recorded replaces it, e.g. with a later seal.
+func (s *FileSystemLogStateStore) Record(tenantID string, logState *TrustedLogState, proof *ConsistencyProof) error {
+
+	s.recordLock.Lock()
+	defer s.recordLock.Unlock()
+
+	latest, err := s.Latest(tenantID)
+	if err != nil && !errors.Is(err, ErrLogStateNotFound) {
+		return err
+	}
+
+	err = checkLogState(latest, logState, proof)
+	if err != nil {
+		return err
+	}
+
+	return s.put(tenantID, logState)
+}
+
+// put writes the given log state as the latest trusted log state for the given tenant,
+// without any consistency check.
+func (s *FileSystemLogStateStore) put(tenantID string, logState *TrustedLogState) error {
+
+	tenantDir, err := s.tenantDir(tenantID)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(tenantDir, 0o755)
+	if err != nil {
+		return err
+	}
+
+	data, err := s.codec.MarshalCBOR(logState)
+	if err != nil {
+		return err
+	}
+
+	filePath := filepath.Join(tenantDir, fmt.Sprintf("%016x%s", logState.LogState.MMRSize, logStateFileExt))
+
+	// write then rename, so a partly written log state is never read as the latest
+	tmpFile, err := os.CreateTemp(tenantDir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(data)
+	if err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	err = tmpFile.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), filePath)
+}
+
+// tenantDir returns the directory the given tenant's log states are kept in.
+func (s *FileSystemLogStateStore) tenantDir(tenantID string) (string, error) {
+
+	localPath := filepath.FromSlash(tenantID)
+
+	// guard against tenant ids escaping the root directory
+	if tenantID == "" || !filepath.IsLocal(localPath) {
+		return "", fmt.Errorf("%w: %s", ErrLogStateTenantPath, tenantID)
+	}
+
+	return filepath.Join(s.rootDir, localPath), nil
+}
+
+// logStateFiles returns the file paths of the given tenant's log states, in mmr size order.
+func (s *FileSystemLogStateStore) logStateFiles(tenantID string) ([]string, error) {
+
+	tenantDir, err := s.tenantDir(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(tenantDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the file names are fixed width hex, so name order is mmr size order
+	filePaths := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), logStateFileExt) {
+			continue
+		}
+
+		filePaths = append(filePaths, filepath.Join(tenantDir, entry.Name()))
+	}
+
+	slices.Sort(filePaths)
+
+	return filePaths, nil
+}
+
+// readLogState reads the trusted log state in the given file.
+func (s *FileSystemLogStateStore) readLogState(filePath string) (*TrustedLogState, error) {
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	logState := &TrustedLogState{}
+	err = s.codec.UnmarshalInto(data, logState)
+	if err != nil {
+		return nil, err
+	}
+
+	return logState, nil
+}
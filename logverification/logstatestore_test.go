@@ -0,0 +1,152 @@
+package logverification
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTrustLogState tests that log states are trusted on first use, and then
+// only recorded if consistent with the latest trusted log state.
+func TestTrustLogState(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+	keyStore := TrustedKeys{localLog.KeyID(): &localLog.signingKey.PublicKey}
+
+	store, err := NewFileSystemLogStateStore(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = store.Latest(localLog.tenantID)
+	assert.ErrorIs(t, err, ErrLogStateNotFound)
+
+	localLog.AppendEntries(3)
+	logStateA := localLog.Seal()
+
+	trustedA, err := TrustLogState(
+		context.Background(), store, localLog.Reader(), localLog.codec, keyStore, localLog.tenantID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, logStateA.Peaks, trustedA.LogState.Peaks)
+
+	localLog.AppendEntries(4)
+	logStateB := localLog.Seal()
+
+	_, err = TrustLogState(
+		context.Background(), store, localLog.Reader(), localLog.codec, keyStore, localLog.tenantID, 0)
+	require.NoError(t, err)
+
+	latest, err := store.Latest(localLog.tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, logStateB.MMRSize, latest.LogState.MMRSize)
+	assert.Equal(t, logStateB.Peaks, latest.LogState.Peaks)
+
+	// the seal is kept with the log state, so it can be verified again later
+	signedState, err := latest.SignedState()
+	require.NoError(t, err)
+
+	_, err = VerifySignedLogState(signedState, localLog.codec, keyStore)
+	require.NoError(t, err)
+
+	history, err := store.History(localLog.tenantID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, logStateA.MMRSize, history[0].LogState.MMRSize)
+	assert.Equal(t, logStateB.MMRSize, history[1].LogState.MMRSize)
+}
+
+// TestFileSystemLogStateStore_Record tests that a log state is refused unless it is proven
+// consistent with the latest trusted log state.
+func TestFileSystemLogStateStore_Record(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+
+	localLog.AppendEntries(3)
+	logStateA := localLog.Seal()
+
+	localLog.AppendEntries(4)
+	logStateB := localLog.Seal()
+
+	proof, err := NewConsistencyProof(
+		context.Background(), localLog.Reader(), localLog.tenantID, logStateA.MMRSize, logStateB.MMRSize)
+	require.NoError(t, err)
+
+	// a log state of the same size as log state B, that does not extend log state A
+	forkedStateB := *logStateB
+	forkedStateB.Peaks = [][]byte{sha256.New().Sum(nil), logStateB.Peaks[1], logStateB.Peaks[2]}
+
+	forkedStateA := *logStateA
+	forkedStateA.Peaks = [][]byte{sha256.New().Sum(nil), logStateA.Peaks[1]}
+
+	tests := []struct {
+		name     string
+		logState *massifs.MMRState
+		proof    *ConsistencyProof
+		err      error
+	}{
+		{
+			name:     "consistent",
+			logState: logStateB,
+			proof:    proof,
+		},
+		{
+			name:     "same log state",
+			logState: logStateA,
+		},
+		{
+			name:     "forked log state",
+			logState: &forkedStateB,
+			proof:    proof,
+			err:      ErrLogStateInconsistent,
+		},
+		{
+			name:     "forked log state of the same size",
+			logState: &forkedStateA,
+			err:      ErrLogStateInconsistent,
+		},
+		{
+			name:     "no proof",
+			logState: logStateB,
+			err:      ErrLogStateProofMissing,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			store, err := NewFileSystemLogStateStore(t.TempDir())
+			require.NoError(t, err)
+
+			// trusted on first use
+			err = store.Record(localLog.tenantID, &TrustedLogState{LogState: logStateA}, nil)
+			require.NoError(t, err)
+
+			err = store.Record(localLog.tenantID, &TrustedLogState{LogState: test.logState}, test.proof)
+
+			latest, latestErr := store.Latest(localLog.tenantID)
+			require.NoError(t, latestErr)
+
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				assert.Equal(t, logStateA.Peaks, latest.LogState.Peaks)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.logState.Peaks, latest.LogState.Peaks)
+		})
+	}
+
+	t.Run("rollback", func(t *testing.T) {
+
+		store, err := NewFileSystemLogStateStore(t.TempDir())
+		require.NoError(t, err)
+
+		err = store.Record(localLog.tenantID, &TrustedLogState{LogState: logStateB}, nil)
+		require.NoError(t, err)
+
+		err = store.Record(localLog.tenantID, &TrustedLogState{LogState: logStateA}, nil)
+		assert.ErrorIs(t, err, ErrLogStateRollback)
+	})
+}
@@ -3,17 +3,17 @@ package logverification
 import (
 	"context"
 	"errors"
-	"time"
+	"fmt"
+	"net/http"
 
+	"github.com/datatrails/go-datatrails-common/azblob"
 	"github.com/datatrails/go-datatrails-merklelog/massifs"
 )
 
-const (
-	contextTimeout = 30 * time.Second
-)
-
 var (
-	ErrNilMassifContext = errors.New("nil massif context")
+	ErrNilMassifContext     = errors.New("nil massif context")
+	ErrMassifHeightMismatch = errors.New("the massif height does not match the massif height in the massif header")
+	ErrMassifHeightMissing  = errors.New("the massif header has no massif height")
 )
 
 type MassifGetter interface {
@@ -22,31 +22,135 @@ type MassifGetter interface {
 	) (massifs.MassifContext, error)
 }
 
+// MassifHeight discovers the massif height of the given tenant's log,
+//
+//	from the header (MassifStart) of the first massif in the log.
+func MassifHeight(ctx context.Context, massifReader MassifGetter, tenantId string) (uint8, error) {
+
+	massif, err := massifReader.GetMassif(ctx, tenantId, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return massif.Start.MassifHeight, nil
+}
+
+// isBlobNotFound returns true if the given error is from reading a blob that does not exist,
+//
+//	from either the file system reader or azure blob storage.
+func isBlobNotFound(err error) bool {
+
+	if errors.Is(err, ErrFileSystemBlobMissing) {
+		return true
+	}
+
+	var httpErr azblob.HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode() == http.StatusNotFound
+}
+
 // Massif gets the massif (blob) that contains the given mmrIndex, from azure blob storage
 //
 //	defined by the azblob configuration.
-func Massif(mmrIndex uint64, massifReader MassifGetter, tenantId string, massifHeight uint8) (*massifs.MassifContext, error) {
+//
+// The given context is passed through to the massif reader, so the caller controls
+// cancellation and any deadline for the read.
+//
+// If the given massif height is 0, the massif height is discovered from the log.
+// Otherwise the given massif height overrides discovery, and must match the height
+// in the header of the massif that is read.
+func Massif(
+	ctx context.Context,
+	mmrIndex uint64,
+	massifReader MassifGetter,
+	tenantId string,
+	massifHeight uint8,
+) (*massifs.MassifContext, error) {
+
+	if massifHeight == 0 {
+		return discoverMassif(ctx, mmrIndex, massifReader, tenantId)
+	}
 
 	massifIndex := massifs.MassifIndexFromMMRIndex(massifHeight, mmrIndex)
 
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-	defer cancel()
-
 	massif, err := massifReader.GetMassif(ctx, tenantId, massifIndex)
 	if err != nil {
 		return nil, err
 	}
 
+	// a massif read with the wrong height contains different mmr indices
+	//  to the ones we expect.
+	if massif.Start.MassifHeight != massifHeight {
+		return nil, fmt.Errorf(
+			"%w: expected %d, got %d", ErrMassifHeightMismatch, massifHeight, massif.Start.MassifHeight)
+	}
+
 	return &massif, nil
 }
 
+// discoverMassif gets the massif that contains the given mmrIndex, discovering the massif
+// height from the header (MassifStart) of the massif that is read.
+//
+// The massif is first read assuming the DefaultMassifHeight, which most logs have, so
+// discovery normally costs a single read. Every massif in a log has the same height, so if
+// the header of the massif read has a different height, the massif for that height is read
+// instead. If there is no massif at the default height, the height is discovered from the
+// first massif in the log.
+func discoverMassif(
+	ctx context.Context,
+	mmrIndex uint64,
+	massifReader MassifGetter,
+	tenantId string,
+) (*massifs.MassifContext, error) {
+
+	defaultIndex := massifs.MassifIndexFromMMRIndex(DefaultMassifHeight, mmrIndex)
+
+	massif, err := massifReader.GetMassif(ctx, tenantId, defaultIndex)
+	if isBlobNotFound(err) {
+
+		massifHeight, err := MassifHeight(ctx, massifReader, tenantId)
+		if err != nil {
+			return nil, err
+		}
+
+		if massifHeight == 0 {
+			return nil, ErrMassifHeightMissing
+		}
+
+		return Massif(ctx, mmrIndex, massifReader, tenantId, massifHeight)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	massifHeight := massif.Start.MassifHeight
+	if massifHeight == 0 {
+		return nil, ErrMassifHeightMissing
+	}
+
+	if massifs.MassifIndexFromMMRIndex(massifHeight, mmrIndex) == defaultIndex {
+		return &massif, nil
+	}
+
+	return Massif(ctx, mmrIndex, massifReader, tenantId, massifHeight)
+}
+
 // UpdateMassifContext, updates the given massifContext to the massif that stores
 //
 //	the given mmrIndex for the given tenant.
 //
 // A Massif is a blob that contains a portion of the merkle log.
 // A MassifContext is the context used to get specific massifs.
-func UpdateMassifContext(massifReader MassifGetter, massifContext *massifs.MassifContext, mmrIndex uint64, tenantID string, massifHeight uint8) error {
+//
+// If the given massif height is 0, the massif height is taken from the header of the
+// current massifContext, or discovered from the log if there is no current massif.
+func UpdateMassifContext(
+	ctx context.Context,
+	massifReader MassifGetter,
+	massifContext *massifs.MassifContext,
+	mmrIndex uint64,
+	tenantID string,
+	massifHeight uint8,
+) error {
 
 	// there is a chance here that massifContext is nil, in this case we can't do anything
 	//  as we set the massifContext as a side effect, and there is no pointer value.
@@ -61,7 +165,13 @@ func UpdateMassifContext(massifReader MassifGetter, massifContext *massifs.Massi
 
 	// if we get here, we know that we need a different massifContext to the given massifContext
 
-	nextContext, err := Massif(mmrIndex, massifReader, tenantID, massifHeight)
+	// every massif in a log has the same height, so the current massif's
+	//  header saves us discovering it again.
+	if massifHeight == 0 && len(massifContext.Data) > 0 {
+		massifHeight = massifContext.Start.MassifHeight
+	}
+
+	nextContext, err := Massif(ctx, mmrIndex, massifReader, tenantID, massifHeight)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,173 @@
+package logverification
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMassif tests:
+//
+// 1. the massif height is discovered from the log, if not given.
+// 2. a massif height that matches the log is honoured.
+// 3. a massif height that does not match the log returns a specific error.
+func TestMassif(t *testing.T) {
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	localLog.AppendEntries(10) // 3 massifs of 4 leaves
+
+	tests := []struct {
+		name                string
+		massifHeight        uint8
+		expectedMassifIndex uint32
+		err                 error
+	}{
+		{
+			name:                "discovered massif height",
+			massifHeight:        0,
+			expectedMassifIndex: 2,
+			err:                 nil,
+		},
+		{
+			name:                "matching massif height",
+			massifHeight:        testLocalLogMassifHeight,
+			expectedMassifIndex: 2,
+			err:                 nil,
+		},
+		{
+			name:         "mismatched massif height",
+			massifHeight: DefaultMassifHeight,
+			err:          ErrMassifHeightMismatch,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			// mmr index 15 is the first leaf of massif 2
+			massifContext, err := Massif(
+				context.Background(), 15, localLog.MassifGetter(), localLog.tenantID, test.massifHeight)
+
+			assert.ErrorIs(t, err, test.err)
+			if test.err != nil {
+				return
+			}
+
+			assert.Equal(t, test.expectedMassifIndex, massifContext.Start.MassifIndex)
+		})
+	}
+}
+
+// countingMassifGetter records the index of every massif read through it.
+type countingMassifGetter struct {
+	MassifGetter
+	reads []uint64
+}
+
+func (g *countingMassifGetter) GetMassif(
+	ctx context.Context, tenantIdentity string, massifIndex uint64, opts ...massifs.ReaderOption,
+) (massifs.MassifContext, error) {
+	g.reads = append(g.reads, massifIndex)
+	return g.MassifGetter.GetMassif(ctx, tenantIdentity, massifIndex, opts...)
+}
+
+// TestMassif_DiscoveryReads tests that discovering the massif height only reads the
+// massifs it needs to, taking the height from the header of the massif read.
+func TestMassif_DiscoveryReads(t *testing.T) {
+
+	defaultLog := newTestLocalLog(t, DefaultMassifHeight)
+	defaultLog.AppendEntries(3)
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	localLog.AppendEntries(10) // 3 massifs of 4 leaves
+
+	tests := []struct {
+		name                string
+		localLog            *testLocalLog
+		mmrIndex            uint64
+		expectedMassifIndex uint32
+		expectedReads       []uint64
+	}{
+		{
+			name:                "default massif height",
+			localLog:            defaultLog,
+			mmrIndex:            3,
+			expectedMassifIndex: 0,
+			expectedReads:       []uint64{0},
+		},
+		{
+			name:                "other massif height, first massif",
+			localLog:            localLog,
+			mmrIndex:            3,
+			expectedMassifIndex: 0,
+			expectedReads:       []uint64{0},
+		},
+		{
+			name:                "other massif height, later massif",
+			localLog:            localLog,
+			mmrIndex:            15,
+			expectedMassifIndex: 2,
+			expectedReads:       []uint64{0, 2},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			massifGetter := &countingMassifGetter{MassifGetter: test.localLog.MassifGetter()}
+
+			massifContext, err := Massif(context.Background(), test.mmrIndex, massifGetter, test.localLog.tenantID, 0)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedMassifIndex, massifContext.Start.MassifIndex)
+			assert.Equal(t, test.expectedReads, massifGetter.reads)
+		})
+	}
+}
+
+// TestVerifyList_MassifHeight tests that lists spanning several massifs, of a height
+// other than the default, verify.
+func TestVerifyList_MassifHeight(t *testing.T) {
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	appEntries := localLog.AppendEntries(10) // 3 massifs of 4 leaves
+
+	omittedMMRIndices, _, err := VerifyList(context.Background(), localLog.Reader(), appEntries)
+	require.NoError(t, err)
+	assert.Empty(t, omittedMMRIndices)
+
+	omittedMMRIndices, _, err = VerifyList(
+		context.Background(), localLog.Reader(), appEntries, WithVerifyMassifHeight(testLocalLogMassifHeight))
+	require.NoError(t, err)
+	assert.Empty(t, omittedMMRIndices)
+
+	_, _, err = VerifyList(
+		context.Background(), localLog.Reader(), appEntries, WithVerifyMassifHeight(DefaultMassifHeight))
+	assert.ErrorIs(t, err, ErrMassifHeightMismatch)
+}
+
+// TestVerifyConsistency_MassifHeight tests that log states in a log of a height
+// other than the default, verify.
+func TestVerifyConsistency_MassifHeight(t *testing.T) {
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+
+	localLog.AppendEntries(9)
+	logStateA := localLog.Seal()
+
+	localLog.AppendEntries(2)
+	logStateB := localLog.Seal()
+
+	verified, err := VerifyConsistency(
+		context.Background(), sha256.New(), localLog.Reader(), localLog.tenantID, logStateA, logStateB)
+	require.NoError(t, err)
+	assert.True(t, verified)
+
+	verified, err = VerifyConsistency(
+		context.Background(), sha256.New(), localLog.Reader(), localLog.tenantID, logStateA, logStateB,
+		WithVerifyMassifHeight(testLocalLogMassifHeight))
+	require.NoError(t, err)
+	assert.True(t, verified)
+}
@@ -0,0 +1,197 @@
+package logverification
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+)
+
+/**
+ * Massif Node Store is an mmr node store that spans every massif in a tenant's log.
+ *
+ * A single massif can only serve the nodes it stores, and the peaks of the massifs before it
+ *  (the ancestor peak stack). Proofs between log states in different massifs need nodes from
+ *  more than one massif, so the store loads massifs as the nodes in them are requested.
+ */
+
+// nodeGetter gets mmr nodes by mmr index, e.g. a single massif, or a MassifNodeStore.
+type nodeGetter interface {
+	Get(i uint64) ([]byte, error)
+}
+
+// laterMassifsNodeGetter gets mmr nodes from a massif, and the nodes beyond the end of it from the
+// massifs after it, through a node store.
+//
+// The nodes before the massif that are needed for a proof are in its ancestor peak stack,
+// so only the later massifs are ever read through the node store.
+type laterMassifsNodeGetter struct {
+	massifContext *massifs.MassifContext
+	nodeStore     *MassifNodeStore
+}
+
+// Get gets the mmr node at the given mmr index.
+func (g *laterMassifsNodeGetter) Get(i uint64) ([]byte, error) {
+
+	if i < g.massifContext.RangeCount() {
+		return g.massifContext.Get(i)
+	}
+
+	return g.nodeStore.Get(i)
+}
+
+// MassifNodeStore gets mmr nodes from any massif in a tenant's log, loading
+// and caching massifs as they are needed.
+//
+// It satisfies the node store interface used by the mmr package,
+// e.g. for mmr.CheckConsistency and mmr.InclusionProof.
+//
+// It is safe for concurrent use. Massifs are cached until they are evicted with EvictTo.
+type MassifNodeStore struct {
+
+	// ctx is used for every massif read, as the mmr node store interface
+	//  does not take a context.
+	ctx context.Context
+
+	massifReader MassifGetter
+	tenantID     string
+
+	// lock guards the massif height and the massif contexts, but is not held
+	//  while a massif is read.
+	lock sync.Mutex
+
+	// massifHeight is 0 until discovered from the log, unless given.
+	massifHeight uint8
+
+	// massifContexts are the massifs read so far, by massif index.
+	massifContexts map[uint64]*massifs.MassifContext
+}
+
+// NewMassifNodeStore creates a new massif node store for the given tenant's log.
+//
+// The given context is used for all the massif reads made by the store.
+//
+// If the given massif height is 0, the massif height is discovered from the log.
+func NewMassifNodeStore(
+	ctx context.Context,
+	massifReader MassifGetter,
+	tenantID string,
+	massifHeight uint8,
+) *MassifNodeStore {
+	return &MassifNodeStore{
+		ctx:            ctx,
+		massifReader:   massifReader,
+		tenantID:       tenantID,
+		massifHeight:   massifHeight,
+		massifContexts: map[uint64]*massifs.MassifContext{},
+	}
+}
+
+// AddMassif adds a massif, already read with a massif reader, to the store, so it is not read again.
+func (s *MassifNodeStore) AddMassif(massifContext *massifs.MassifContext) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.massifHeight == 0 {
+		s.massifHeight = massifContext.Start.MassifHeight
+	}
+
+	s.massifContexts[uint64(massifContext.Start.MassifIndex)] = massifContext
+}
+
+// EvictTo drops the cached massifs up to and including the given massif index,
+// e.g. once every proof that needs them has been made.
+//
+// An evicted massif is read again if any of its nodes are requested.
+func (s *MassifNodeStore) EvictTo(massifIndex uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for cachedIndex := range s.massifContexts {
+		if cachedIndex <= massifIndex {
+			delete(s.massifContexts, cachedIndex)
+		}
+	}
+}
+
+// Get gets the mmr node at the given mmr index.
+func (s *MassifNodeStore) Get(i uint64) ([]byte, error) {
+
+	massifHeight, err := s.height()
+	if err != nil {
+		return nil, err
+	}
+
+	massifIndex := massifs.MassifIndexFromMMRIndex(massifHeight, i)
+
+	value, ok, err := s.cachedNode(i, massifIndex)
+	if ok {
+		return value, err
+	}
+
+	massifContext, err := Massif(s.ctx, i, s.massifReader, s.tenantID, massifHeight)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the massif for mmr index %d: %w", i, err)
+	}
+
+	s.lock.Lock()
+	s.massifContexts[massifIndex] = massifContext
+	s.lock.Unlock()
+
+	return massifContext.Get(i)
+}
+
+// height gets the massif height of the log, discovering it from the log if it is not known.
+func (s *MassifNodeStore) height() (uint8, error) {
+
+	s.lock.Lock()
+	massifHeight := s.massifHeight
+	s.lock.Unlock()
+
+	if massifHeight != 0 {
+		return massifHeight, nil
+	}
+
+	massifHeight, err := MassifHeight(s.ctx, s.massifReader, s.tenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.lock.Lock()
+	s.massifHeight = massifHeight
+	s.lock.Unlock()
+
+	return massifHeight, nil
+}
+
+// cachedNode gets the mmr node at the given mmr index, in the massif at the given massif index,
+// from the massifs already read.
+//
+// Returns false if none of the massifs already read has the node.
+func (s *MassifNodeStore) cachedNode(i uint64, massifIndex uint64) ([]byte, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	massifContext, ok := s.massifContexts[massifIndex]
+	if ok {
+		value, err := massifContext.Get(i)
+		return value, true, err
+	}
+
+	// peaks of earlier massifs are in the ancestor peak stack of every later massif,
+	//  so check the massifs we already have before reading another.
+	for _, laterContext := range s.massifContexts {
+
+		if uint64(laterContext.Start.MassifIndex) <= massifIndex {
+			continue
+		}
+
+		value, err := laterContext.Get(i)
+		if err == nil {
+			return value, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
@@ -0,0 +1,95 @@
+package logverification
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/datatrails/go-datatrails-merklelog/mmr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMassifNodeStore_Get tests that every node in a log spanning several massifs
+// can be got from the store, matching the node in the massif that stores it.
+func TestMassifNodeStore_Get(t *testing.T) {
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	localLog.AppendEntries(11) // 3 massifs of 4 leaves
+
+	nodeStore := NewMassifNodeStore(context.Background(), localLog.MassifGetter(), localLog.tenantID, 0)
+
+	mmrSize := mmr.FirstMMRSize(mmr.MMRIndex(10))
+
+	for i := range mmrSize {
+
+		massifIndex := massifs.MassifIndexFromMMRIndex(testLocalLogMassifHeight, i)
+
+		expected, err := localLog.Massif(massifIndex).Get(i)
+		require.NoError(t, err)
+
+		actual, err := nodeStore.Get(i)
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, actual, "mmr index %d", i)
+	}
+}
+
+// TestVerifyConsistency_AcrossMassifs tests that log states in different massifs verify.
+func TestVerifyConsistency_AcrossMassifs(t *testing.T) {
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+
+	localLog.AppendEntries(3) // massif 0
+	logStateA := localLog.Seal()
+
+	localLog.AppendEntries(3) // massif 1
+	logStateB := localLog.Seal()
+
+	localLog.AppendEntries(7) // massif 3
+	logStateC := localLog.Seal()
+
+	tamperedStateA := *logStateA
+	tamperedStateA.Peaks = [][]byte{sha256.New().Sum(nil), logStateA.Peaks[1]}
+
+	tests := []struct {
+		name      string
+		logStateA *massifs.MMRState
+		logStateB *massifs.MMRState
+		expected  bool
+	}{
+		{
+			name:      "massif 0 to massif 1",
+			logStateA: logStateA,
+			logStateB: logStateB,
+			expected:  true,
+		},
+		{
+			name:      "massif 0 to massif 3",
+			logStateA: logStateA,
+			logStateB: logStateC,
+			expected:  true,
+		},
+		{
+			name:      "massif 1 to massif 3",
+			logStateA: logStateB,
+			logStateB: logStateC,
+			expected:  true,
+		},
+		{
+			name:      "tampered massif 0 to massif 3",
+			logStateA: &tamperedStateA,
+			logStateB: logStateC,
+			expected:  false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verified, _ := VerifyConsistency(
+				context.Background(), sha256.New(), localLog.Reader(), localLog.tenantID, test.logStateA, test.logStateB)
+
+			assert.Equal(t, test.expected, verified)
+		})
+	}
+}
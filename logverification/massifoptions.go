@@ -11,10 +11,6 @@ type MassifOptions struct {
 	// TenantId is an optional tenant ID to use instead
 	//  of the TenantId found on the eventJson.
 	TenantId string
-
-	// MassifHeight is an optional massif height for the massif
-	//  instead of the default.
-	MassifHeight uint8
 }
 
 type MassifOption func(*MassifOptions)
@@ -34,18 +30,10 @@ func WithMassifTenantId(tenantId string) MassifOption {
 	return func(mo *MassifOptions) { mo.TenantId = tenantId }
 }
 
-// WithMassifHeight is an optional massif height for the massif
-//
-//	instead of the default.
-func WithMassifHeight(massifHeight uint8) MassifOption {
-	return func(mo *MassifOptions) { mo.MassifHeight = massifHeight }
-}
-
 // ParseMassifOptions parses the given options into a MassifOptions struct
 func ParseMassifOptions(options ...MassifOption) MassifOptions {
 	massifOptions := MassifOptions{
-		NonLeafNode:  false,               // default to erroring on non leaf nodes
-		MassifHeight: DefaultMassifHeight, // set the default massif height first
+		NonLeafNode: false, // default to erroring on non leaf nodes
 	}
 
 	for _, option := range options {
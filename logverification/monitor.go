@@ -0,0 +1,457 @@
+package logverification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/datatrails/go-datatrails-common/azblob"
+	"github.com/datatrails/go-datatrails-common/cbor"
+	"github.com/datatrails/go-datatrails-common/logger"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+)
+
+/**
+ * Log Monitor continuously checks the seals of tenants' logs for consistency.
+ *
+ * On each check, for each tenant, the monitor:
+ *
+ *  1. Seal        - finds the newest massif seal, from the massif of the latest trusted log state onwards.
+ *  2. Signature   - verifies the seal signature against the trusted key store.
+ *  3. Consistency - proves the sealed log state is consistent with the latest trusted log state.
+ *  4. Record      - records the sealed log state as the latest trusted log state.
+ *
+ * The first seal checked for a tenant is trusted on first use.
+ *
+ * A seal that fails its signature, or is not consistent with the latest trusted log state,
+ *  raises an alert, as does the massif or seal of the latest trusted log state going missing.
+ *  A seal that could not be checked, e.g. because the log could not be read, does not, and is
+ *  checked again next time.
+ */
+
+const (
+	DefaultMonitorInterval = 5 * time.Minute
+)
+
+var (
+	ErrMonitorTenantsRequired = errors.New("the monitor needs at least one tenant to check")
+	ErrMonitorAlert           = errors.New("the monitor raised an alert")
+	ErrMonitorWebhookStatus   = errors.New("the monitor webhook did not accept the alert")
+	ErrMonitorNoSeal          = errors.New("the log has no sealed massif to check")
+
+	ErrMonitorTrustedMassifMissing = errors.New("the massif of the latest trusted log state is missing from the log")
+	ErrMonitorTrustedSealMissing   = errors.New("the seal of the massif of the latest trusted log state is missing from the log")
+)
+
+// MonitorStatus is the outcome of a monitor check of a tenant's log.
+type MonitorStatus int
+
+const (
+	// MonitorStatusError is a check that could not be completed, e.g. the log could not be read.
+	MonitorStatusError MonitorStatus = iota
+
+	// MonitorStatusTrusted is a seal that is verified, and consistent with the latest trusted log state.
+	MonitorStatusTrusted
+
+	// MonitorStatusUntrusted is a seal that failed its signature verification.
+	MonitorStatusUntrusted
+
+	// MonitorStatusInconsistent is a seal that is not consistent with the latest trusted log state.
+	MonitorStatusInconsistent
+)
+
+// String returns the name of the status.
+func (s MonitorStatus) String() string {
+	switch s {
+	case MonitorStatusError:
+		return "error"
+	case MonitorStatusTrusted:
+		return "trusted"
+	case MonitorStatusUntrusted:
+		return "untrusted"
+	case MonitorStatusInconsistent:
+		return "inconsistent"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// MarshalText encodes the status as its name.
+func (s MonitorStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Alert returns true if the status should raise an alert.
+func (s MonitorStatus) Alert() bool {
+	return s == MonitorStatusUntrusted || s == MonitorStatusInconsistent
+}
+
+// MonitorResult is the result of a monitor check of a tenant's log.
+type MonitorResult struct {
+	TenantID    string        `json:"tenant_id"`
+	Status      MonitorStatus `json:"status"`
+	MassifIndex uint64        `json:"massif_index"`
+
+	// MMRSize is the size of the sealed log state checked, 0 if the seal could not be read.
+	MMRSize uint64 `json:"mmr_size"`
+
+	// Err is the reason the check did not trust the seal, nil if it did.
+	Err error `json:"-"`
+
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// MarshalJSON encodes the result, with the error as a string.
+func (r MonitorResult) MarshalJSON() ([]byte, error) {
+
+	type monitorResult MonitorResult
+
+	errStr := ""
+	if r.Err != nil {
+		errStr = r.Err.Error()
+	}
+
+	return json.Marshal(struct {
+		monitorResult
+		Error string `json:"error,omitempty"`
+	}{
+		monitorResult: monitorResult(r),
+		Error:         errStr,
+	})
+}
+
+// Alerter raises an alert for a monitor result.
+type Alerter interface {
+	Alert(ctx context.Context, result MonitorResult) error
+}
+
+// LogAlerter raises alerts as error log lines.
+type LogAlerter struct{}
+
+// Alert logs the given result as an error.
+func (a LogAlerter) Alert(ctx context.Context, result MonitorResult) error {
+	logger.Sugar.Errorf("log monitor alert: tenant %s, massif %d, mmr size %d, %s: %v",
+		result.TenantID, result.MassifIndex, result.MMRSize, result.Status, result.Err)
+	return nil
+}
+
+// WebhookAlerter raises alerts by posting the result, as JSON, to a webhook url.
+type WebhookAlerter struct {
+	URL    string
+	Client *http.Client
+}
+
+// Alert posts the given result to the webhook url.
+func (a WebhookAlerter) Alert(ctx context.Context, result MonitorResult) error {
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("%w: status %d", ErrMonitorWebhookStatus, response.StatusCode)
+	}
+
+	return nil
+}
+
+// Monitor continuously checks the seals of tenants' logs for consistency.
+type Monitor struct {
+	reader   azblob.Reader
+	store    LogStateStore
+	keyStore KeyStore
+	codec    cbor.CBORCodec
+	tenants  []string
+
+	interval    time.Duration
+	alerters    []Alerter
+	exitOnAlert bool
+
+	verifyOptions []VerifyOption
+}
+
+type MonitorOption func(*Monitor)
+
+// WithMonitorInterval is the time between checks, instead of DefaultMonitorInterval.
+func WithMonitorInterval(interval time.Duration) MonitorOption {
+	return func(m *Monitor) { m.interval = interval }
+}
+
+// WithMonitorAlerter adds an alerter, alerts are raised with every alerter added.
+//
+// If no alerter is added, alerts are raised with a LogAlerter.
+func WithMonitorAlerter(alerter Alerter) MonitorOption {
+	return func(m *Monitor) { m.alerters = append(m.alerters, alerter) }
+}
+
+// WithMonitorExitOnAlert stops Run with ErrMonitorAlert after the first check that raises an alert.
+func WithMonitorExitOnAlert() MonitorOption {
+	return func(m *Monitor) { m.exitOnAlert = true }
+}
+
+// WithMonitorVerifyOptions are the verify options used to check the logs,
+// e.g. WithVerifyMassifHeight.
+func WithMonitorVerifyOptions(options ...VerifyOption) MonitorOption {
+	return func(m *Monitor) { m.verifyOptions = append(m.verifyOptions, options...) }
+}
+
+// NewMonitor creates a new monitor of the given tenants' logs, read with the given reader.
+//
+// Seals are verified against the given key store, and the trusted log states are kept in the given store.
+func NewMonitor(
+	reader azblob.Reader,
+	store LogStateStore,
+	keyStore KeyStore,
+	tenants []string,
+	options ...MonitorOption,
+) (*Monitor, error) {
+
+	if len(tenants) == 0 {
+		return nil, ErrMonitorTenantsRequired
+	}
+
+	codec, err := massifs.NewRootSignerCodec()
+	if err != nil {
+		return nil, err
+	}
+
+	monitor := &Monitor{
+		reader:   reader,
+		store:    store,
+		keyStore: keyStore,
+		codec:    codec,
+		tenants:  tenants,
+		interval: DefaultMonitorInterval,
+	}
+
+	for _, option := range options {
+		option(monitor)
+	}
+
+	if len(monitor.alerters) == 0 {
+		monitor.alerters = []Alerter{LogAlerter{}}
+	}
+
+	return monitor, nil
+}
+
+// Run checks every tenant's log, then again every interval, until the given context is done.
+//
+// Returns nil when the context is done, or ErrMonitorAlert if the monitor exits on alert,
+// and a check raised an alert.
+func (m *Monitor) Run(ctx context.Context) error {
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+
+		results := m.Check(ctx)
+
+		if m.exitOnAlert && Alerted(results) {
+			return ErrMonitorAlert
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check checks every tenant's log once, raising an alert for any result that needs one.
+//
+// Returns the result for each tenant, in the order the tenants were given.
+func (m *Monitor) Check(ctx context.Context) []MonitorResult {
+
+	results := []MonitorResult{}
+
+	for _, tenantID := range m.tenants {
+
+		result := m.checkTenant(ctx, tenantID)
+
+		if result.Status.Alert() {
+			for _, alerter := range m.alerters {
+
+				err := alerter.Alert(ctx, result)
+				if err != nil {
+					logger.Sugar.Errorf("log monitor: unable to raise alert for tenant %s: %v", tenantID, err)
+				}
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// Alerted returns true if any of the given results raised an alert.
+func Alerted(results []MonitorResult) bool {
+
+	for _, result := range results {
+		if result.Status.Alert() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkTenant checks the newest seal of the given tenant's log.
+func (m *Monitor) checkTenant(ctx context.Context, tenantID string) MonitorResult {
+
+	result := MonitorResult{
+		TenantID:  tenantID,
+		CheckedAt: time.Now().UTC(),
+	}
+
+	massifIndex, err := m.newestSealedMassif(ctx, tenantID)
+	if errors.Is(err, ErrMonitorTrustedMassifMissing) || errors.Is(err, ErrMonitorTrustedSealMissing) {
+		result.Status = MonitorStatusInconsistent
+		result.Err = err
+		return result
+	}
+	if err != nil {
+		result.Status = MonitorStatusError
+		result.Err = err
+		return result
+	}
+
+	result.MassifIndex = massifIndex
+
+	trustedState, err := TrustLogState(
+		ctx, m.store, m.reader, m.codec, m.keyStore, tenantID, massifIndex, m.verifyOptions...)
+	if trustedState != nil {
+		result.MMRSize = trustedState.LogState.MMRSize
+	}
+
+	switch {
+	case err == nil:
+		result.Status = MonitorStatusTrusted
+	case errors.Is(err, ErrSealSignatureVerify),
+		errors.Is(err, ErrUntrustedSealKey),
+		errors.Is(err, ErrSealKeyNotValid),
+		errors.Is(err, ErrSealKeyIDMissing):
+		result.Status = MonitorStatusUntrusted
+	case errors.Is(err, ErrLogStateInconsistent),
+		errors.Is(err, ErrLogStateRollback):
+		result.Status = MonitorStatusInconsistent
+	default:
+		result.Status = MonitorStatusError
+	}
+
+	result.Err = err
+
+	return result
+}
+
+// newestSealedMassif finds the index of the newest massif of the given tenant's log that has a seal,
+// from the massif of the latest trusted log state onwards.
+//
+// NOTE: blobs can not be listed from every reader, so each following massif is tried in turn
+// to find the head massif. The head massif may not be sealed yet, so the massifs before it
+// are tried in turn for a seal.
+func (m *Monitor) newestSealedMassif(ctx context.Context, tenantID string) (uint64, error) {
+
+	massifReader := massifs.NewMassifReader(logger.Sugar, m.reader)
+
+	firstIndex := uint64(0)
+
+	latest, err := m.store.Latest(tenantID)
+	if err != nil && !errors.Is(err, ErrLogStateNotFound) {
+		return 0, err
+	}
+
+	if latest != nil {
+
+		massifHeight := ParseOptions(m.verifyOptions...).massifHeight
+		if massifHeight == 0 {
+
+			// every trusted log state covers the first massif
+			massifHeight, err = MassifHeight(ctx, &massifReader, tenantID)
+			if isBlobNotFound(err) {
+				return 0, fmt.Errorf("%w: massif 0", ErrMonitorTrustedMassifMissing)
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		firstIndex = massifs.MassifIndexFromMMRIndex(massifHeight, latest.LogState.MMRSize-1)
+	}
+
+	// the massif and seal the latest trusted log state was verified from must still be there,
+	//  a log that has dropped them is no longer consistent with it.
+	if latest != nil {
+
+		_, err = massifReader.GetMassif(ctx, tenantID, firstIndex)
+		if isBlobNotFound(err) {
+			return 0, fmt.Errorf("%w: massif %d", ErrMonitorTrustedMassifMissing, firstIndex)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		sealed, err := MassifSealed(ctx, m.reader, m.codec, tenantID, firstIndex)
+		if err != nil {
+			return 0, err
+		}
+
+		if !sealed {
+			return 0, fmt.Errorf("%w: massif %d", ErrMonitorTrustedSealMissing, firstIndex)
+		}
+	}
+
+	headIndex := firstIndex
+	for {
+		_, err = massifReader.GetMassif(ctx, tenantID, headIndex+1)
+		if isBlobNotFound(err) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		headIndex++
+	}
+
+	for massifIndex := headIndex; ; massifIndex-- {
+
+		sealed, err := MassifSealed(ctx, m.reader, m.codec, tenantID, massifIndex)
+		if err != nil {
+			return 0, err
+		}
+
+		if sealed {
+			return massifIndex, nil
+		}
+
+		if massifIndex == firstIndex {
+			return 0, ErrMonitorNoSeal
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package logverification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/datatrails/go-datatrails-common/logger"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAlerter records the alerts it is given.
+type testAlerter struct {
+	alerts []MonitorResult
+}
+
+func (a *testAlerter) Alert(ctx context.Context, result MonitorResult) error {
+	a.alerts = append(a.alerts, result)
+	return nil
+}
+
+// TestMonitor_Check tests the monitor end to end, against logs on the local file system,
+// as the log grows, and is then forked.
+func TestMonitor_Check(t *testing.T) {
+	logger.New("TestMonitor_Check")
+	defer logger.OnExit()
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+
+	// the fork of the log has the same tenant, and is sealed with the same key, but has different entries
+	forkedLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	forkedLog.signingKey = localLog.signingKey
+
+	keyStore := TrustedKeys{localLog.KeyID(): &localLog.signingKey.PublicKey}
+
+	store, err := NewFileSystemLogStateStore(t.TempDir())
+	require.NoError(t, err)
+
+	alerter := &testAlerter{}
+
+	monitor, err := NewMonitor(
+		localLog.Reader(), store, keyStore, []string{localLog.tenantID}, WithMonitorAlerter(alerter))
+	require.NoError(t, err)
+
+	// nothing is sealed yet
+	localLog.AppendEntries(3)
+
+	results := monitor.Check(context.Background())
+	require.Len(t, results, 1)
+	assert.Equal(t, MonitorStatusError, results[0].Status)
+
+	// trusted on first use
+	logStateA := localLog.Seal()
+
+	results = monitor.Check(context.Background())
+	require.Len(t, results, 1)
+	assert.Equal(t, MonitorStatusTrusted, results[0].Status)
+	assert.Equal(t, logStateA.MMRSize, results[0].MMRSize)
+
+	// the log grows by two massifs, only the head massif is sealed
+	localLog.AppendEntries(6)
+	logStateB := localLog.Seal()
+
+	results = monitor.Check(context.Background())
+	require.Len(t, results, 1)
+	assert.Equal(t, MonitorStatusTrusted, results[0].Status)
+	assert.Equal(t, uint64(2), results[0].MassifIndex)
+	assert.Equal(t, logStateB.MMRSize, results[0].MMRSize)
+
+	assert.Empty(t, alerter.alerts)
+
+	// the forked log is bigger, but not consistent with the trusted log state
+	forkedLog.AppendEntries(11)
+	forkedLog.Seal()
+
+	forkedMonitor, err := NewMonitor(
+		forkedLog.Reader(), store, keyStore, []string{forkedLog.tenantID}, WithMonitorAlerter(alerter))
+	require.NoError(t, err)
+
+	results = forkedMonitor.Check(context.Background())
+	require.Len(t, results, 1)
+	assert.Equal(t, MonitorStatusInconsistent, results[0].Status)
+	assert.ErrorIs(t, results[0].Err, ErrLogStateInconsistent)
+
+	require.Len(t, alerter.alerts, 1)
+	assert.Equal(t, MonitorStatusInconsistent, alerter.alerts[0].Status)
+
+	// the fork is never recorded
+	latest, err := store.Latest(localLog.tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, logStateB.Peaks, latest.LogState.Peaks)
+}
+
+// TestMonitor_Untrusted tests that a seal signed with an untrusted key raises an alert.
+func TestMonitor_Untrusted(t *testing.T) {
+	logger.New("TestMonitor_Untrusted")
+	defer logger.OnExit()
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	localLog.AppendEntries(3)
+	localLog.Seal()
+
+	store, err := NewFileSystemLogStateStore(t.TempDir())
+	require.NoError(t, err)
+
+	alerter := &testAlerter{}
+
+	monitor, err := NewMonitor(
+		localLog.Reader(), store, TrustedKeys{}, []string{localLog.tenantID},
+		WithMonitorAlerter(alerter), WithMonitorExitOnAlert(), WithMonitorInterval(time.Millisecond))
+	require.NoError(t, err)
+
+	err = monitor.Run(context.Background())
+	assert.ErrorIs(t, err, ErrMonitorAlert)
+
+	require.Len(t, alerter.alerts, 1)
+	assert.Equal(t, MonitorStatusUntrusted, alerter.alerts[0].Status)
+	assert.ErrorIs(t, alerter.alerts[0].Err, ErrUntrustedSealKey)
+
+	_, err = store.Latest(localLog.tenantID)
+	assert.ErrorIs(t, err, ErrLogStateNotFound)
+}
+
+// TestMonitor_TrustedMissing tests that the massif or seal of the latest trusted log state
+// going missing from the log raises an inconsistent alert, rather than an error.
+func TestMonitor_TrustedMissing(t *testing.T) {
+	logger.New("TestMonitor_TrustedMissing")
+	defer logger.OnExit()
+
+	tests := []struct {
+		name     string
+		blobPath func(tenantID string) string
+		err      error
+	}{
+		{
+			name: "trusted massif missing",
+			blobPath: func(tenantID string) string {
+				return massifs.TenantMassifBlobPath(tenantID, 1)
+			},
+			err: ErrMonitorTrustedMassifMissing,
+		},
+		{
+			name: "trusted seal missing",
+			blobPath: func(tenantID string) string {
+				return massifs.TenantMassifSignedRootPath(tenantID, 1)
+			},
+			err: ErrMonitorTrustedSealMissing,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+			localLog.AppendEntries(6) // 4 leaves per massif, so 2 massifs
+			localLog.Seal()
+
+			store, err := NewFileSystemLogStateStore(t.TempDir())
+			require.NoError(t, err)
+
+			alerter := &testAlerter{}
+
+			monitor, err := NewMonitor(
+				localLog.Reader(), store, TrustedKeys{localLog.KeyID(): &localLog.signingKey.PublicKey},
+				[]string{localLog.tenantID}, WithMonitorAlerter(alerter))
+			require.NoError(t, err)
+
+			results := monitor.Check(context.Background())
+			require.Len(t, results, 1)
+			assert.Equal(t, MonitorStatusTrusted, results[0].Status)
+			assert.Equal(t, uint64(1), results[0].MassifIndex)
+
+			err = os.Remove(filepath.Join(localLog.rootDir, filepath.FromSlash(test.blobPath(localLog.tenantID))))
+			require.NoError(t, err)
+
+			results = monitor.Check(context.Background())
+			require.Len(t, results, 1)
+			assert.Equal(t, MonitorStatusInconsistent, results[0].Status)
+			assert.ErrorIs(t, results[0].Err, test.err)
+
+			require.Len(t, alerter.alerts, 1)
+			assert.Equal(t, MonitorStatusInconsistent, alerter.alerts[0].Status)
+		})
+	}
+}
+
+// TestWebhookAlerter tests that alerts are posted to the webhook as JSON.
+func TestWebhookAlerter(t *testing.T) {
+
+	received := map[string]any{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := json.NewDecoder(r.Body).Decode(&received)
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	alerter := WebhookAlerter{URL: server.URL}
+
+	err := alerter.Alert(context.Background(), MonitorResult{
+		TenantID:    testLocalLogTenantID,
+		Status:      MonitorStatusInconsistent,
+		MassifIndex: 2,
+		MMRSize:     19,
+		Err:         ErrLogStateInconsistent,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, testLocalLogTenantID, received["tenant_id"])
+	assert.Equal(t, "inconsistent", received["status"])
+	assert.Equal(t, ErrLogStateInconsistent.Error(), received["error"])
+}
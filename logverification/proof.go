@@ -1,7 +1,11 @@
 package logverification
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/bits"
 
 	"github.com/datatrails/go-datatrails-merklelog/massifs"
 	"github.com/datatrails/go-datatrails-merklelog/mmr"
@@ -10,13 +14,30 @@ import (
 /**
  * Utility functions or generating a datatrails merkle log event proof and
  *   verifying that proof.
+ *
+ * These work for anything with an mmr index and a derivable mmr entry, e.g. an app.AppEntry,
+ *  a DecodedEvent, or an app's own VerifiableAppEntry.
+ *
+ * Proofs are against the size of the massif, unless made against the size of a signed view of
+ *  the log with WithVerifyMMRSize, e.g. the MMRSize of a verified log state. Only the size is bound,
+ *  the proof is still verified against the peaks of the massif data.
+ *
+ * Light clients, holding only the signed peaks of a log state and an inclusion proof, can verify
+ *  inclusion without any massif, using VerifyProofInPeaks.
  */
 
+var (
+	ErrProofPeaksMismatch       = errors.New("the number of peaks does not match the mmr size")
+	ErrProofIndexBeyondSize     = errors.New("the mmr index is not within the mmr size")
+	ErrProofMMRSizeBeyondMassif = errors.New("the mmr size is beyond the end of the massif")
+)
+
 // VerifiableMMREntry is an MMR Entry that can have its inclusion verified
 type VerifiableMMREntry interface {
 
-	// MMREntry returns the mmr entry to verify the inclusion of.
-	MMREntry() ([]byte, error)
+	// MMREntry returns the mmr entry to verify the inclusion of,
+	// given the massif that contains it, for any fields sourced from the log.
+	MMREntry(massifContext *massifs.MassifContext) ([]byte, error)
 
 	// MMRIndex returns the mmr index of the mmr entry.
 	MMRIndex() uint64
@@ -24,9 +45,21 @@ type VerifiableMMREntry interface {
 
 // EventProof gets the event proof for the given event and the given massif the event
 // is contained in.
-func EventProof(verifiableMMREntry VerifiableMMREntry, massif *massifs.MassifContext) ([][]byte, error) {
-	// Get the size of the complete tenant MMR
-	mmrSize := massif.RangeCount()
+//
+// The options argument can be the following:
+//
+//	WithVerifyMMRSize - the size of the log to prove inclusion against, e.g. the size
+//	                    of a verified log state, instead of the size of the massif.
+func EventProof(
+	verifiableMMREntry VerifiableMMREntry,
+	massif *massifs.MassifContext,
+	options ...VerifyOption,
+) ([][]byte, error) {
+
+	mmrSize, err := proofMMRSize(verifiableMMREntry, massif, ParseOptions(options...))
+	if err != nil {
+		return nil, err
+	}
 
 	proof, err := mmr.InclusionProof(massif, mmrSize-1, verifiableMMREntry.MMRIndex())
 	if err != nil {
@@ -37,13 +70,26 @@ func EventProof(verifiableMMREntry VerifiableMMREntry, massif *massifs.MassifCon
 }
 
 // VerifyProof verifies the given proof against the given event
-func VerifyProof(verifiableMMREntry VerifiableMMREntry, proof [][]byte, massif *massifs.MassifContext) (bool, error) {
-	// Get the size of the complete tenant MMR
-	mmrSize := massif.RangeCount()
+//
+// The options argument can be the following:
+//
+//	WithVerifyMMRSize - the size of the log the proof is against, e.g. the size
+//	                    of a verified log state, instead of the size of the massif.
+func VerifyProof(
+	verifiableMMREntry VerifiableMMREntry,
+	proof [][]byte,
+	massif *massifs.MassifContext,
+	options ...VerifyOption,
+) (bool, error) {
+
+	mmrSize, err := proofMMRSize(verifiableMMREntry, massif, ParseOptions(options...))
+	if err != nil {
+		return false, err
+	}
 
 	hasher := sha256.New()
 
-	mmrEntry, err := verifiableMMREntry.MMREntry()
+	mmrEntry, err := verifiableMMREntry.MMREntry(massif)
 	if err != nil {
 		return false, err
 	}
@@ -51,3 +97,98 @@ func VerifyProof(verifiableMMREntry VerifiableMMREntry, proof [][]byte, massif *
 	return mmr.VerifyInclusion(massif, hasher, mmrSize, mmrEntry,
 		verifiableMMREntry.MMRIndex(), proof)
 }
+
+// VerifyInclusion verifies the inclusion of the given event against the given massif
+// the event is contained in.
+//
+// Returns true if the event is included on the log, otherwise false.
+//
+// The options argument can be the following:
+//
+//	WithVerifyMMRSize - the size of the log to prove inclusion against, e.g. the size
+//	                    of a verified log state, instead of the size of the massif.
+func VerifyInclusion(
+	verifiableMMREntry VerifiableMMREntry,
+	massif *massifs.MassifContext,
+	options ...VerifyOption,
+) (bool, error) {
+
+	proof, err := EventProof(verifiableMMREntry, massif, options...)
+	if err != nil {
+		return false, err
+	}
+
+	return VerifyProof(verifiableMMREntry, proof, massif, options...)
+}
+
+// proofMMRSize gets the size of the log to prove the given event against.
+//
+// This is the mmr size given in the options, or the size of the massif if not given.
+// The massif must hold every node of a log of that size, so it can be no bigger than the massif.
+func proofMMRSize(
+	verifiableMMREntry VerifiableMMREntry,
+	massif *massifs.MassifContext,
+	verifyOptions VerifyOptions,
+) (uint64, error) {
+
+	// Get the size of the complete tenant MMR
+	mmrSize := massif.RangeCount()
+
+	if verifyOptions.mmrSize != 0 {
+
+		if verifyOptions.mmrSize > mmrSize {
+			return 0, fmt.Errorf("%w: mmr size %d, massif size %d",
+				ErrProofMMRSizeBeyondMassif, verifyOptions.mmrSize, mmrSize)
+		}
+
+		mmrSize = verifyOptions.mmrSize
+	}
+
+	if verifiableMMREntry.MMRIndex() >= mmrSize {
+		return 0, fmt.Errorf("%w: mmr index %d, mmr size %d",
+			ErrProofIndexBeyondSize, verifiableMMREntry.MMRIndex(), mmrSize)
+	}
+
+	return mmrSize, nil
+}
+
+// VerifyProofInPeaks verifies the given inclusion proof of the given mmr entry (leaf hash),
+// against the peaks of an mmr of the given size, e.g. the MMRSize and Peaks of a signed MMRState.
+//
+// Only the peaks are needed, so this does not need any access to the log. For an app entry,
+// the mmr entry can be derived without the log using app.AppEntry.MMREntryFromSalt.
+//
+// Returns true if the mmr entry is included under one of the peaks, otherwise false, with an
+// error wrapping mmr.ErrVerifyInclusionFailed, in the same way as VerifyProof.
+func VerifyProofInPeaks(
+	mmrEntry []byte,
+	mmrIndex uint64,
+	mmrSize uint64,
+	proof [][]byte,
+	peaks [][]byte,
+) (bool, error) {
+
+	if mmrIndex >= mmrSize {
+		return false, fmt.Errorf("%w: mmr index %d, mmr size %d", ErrProofIndexBeyondSize, mmrIndex, mmrSize)
+	}
+
+	// there is a peak for every set bit of the leaf count
+	leafCount := mmr.LeafCount(mmrSize)
+	if len(peaks) != bits.OnesCount64(leafCount) {
+		return false, fmt.Errorf("%w: expected %d peaks, got %d",
+			ErrProofPeaksMismatch, bits.OnesCount64(leafCount), len(peaks))
+	}
+
+	peakIndex := mmr.PeakIndex(leafCount, len(proof))
+	if peakIndex >= len(peaks) {
+		return false, fmt.Errorf(
+			"%w: proof length %d does not lead to a peak", mmr.ErrVerifyInclusionFailed, len(proof))
+	}
+
+	root := mmr.IncludedRoot(sha256.New(), mmrIndex, mmrEntry, proof)
+	if !bytes.Equal(root, peaks[peakIndex]) {
+		return false, fmt.Errorf("%w: proven root is not the peak", mmr.ErrVerifyInclusionFailed)
+	}
+
+	return true, nil
+}
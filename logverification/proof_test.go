@@ -0,0 +1,308 @@
+package logverification
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/datatrails/go-datatrails-merklelog/mmr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVerifyInclusion tests that app entries and decoded events are verified
+// by the same proof functions.
+func TestVerifyInclusion(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+	appEntries := localLog.AppendEntries(3)
+	events := localLog.AppendEvents(3)
+
+	decodedEvent, err := NewDecodedEvent(events[1])
+	require.NoError(t, err)
+
+	tamperedEvent, err := NewDecodedEvent(events[1])
+	require.NoError(t, err)
+	tamperedEvent.V3Event.Operation = "Tampered"
+
+	// the event claims a different idtimestamp to the one on the log
+	wrongIDTimestampEvent, err := NewDecodedEvent(events[2])
+	require.NoError(t, err)
+	wrongIDTimestampEvent.MerkleLog.Commit.Idtimestamp = massifs.IDTimestampToHex(1234, 1)
+
+	tamperedEntry := app.NewAppEntry(
+		appEntries[1].AppID(),
+		appEntries[1].LogID(),
+		app.NewMMREntryFields(0, []byte(`{"identity":"tampered"}`)),
+		appEntries[1].MMRIndex(),
+	)
+
+	tests := []struct {
+		name     string
+		entry    VerifiableMMREntry
+		expected bool
+	}{
+		{
+			name:     "app entry",
+			entry:    appEntries[1],
+			expected: true,
+		},
+		{
+			name:     "decoded event",
+			entry:    decodedEvent,
+			expected: true,
+		},
+		{
+			name:     "tampered app entry",
+			entry:    tamperedEntry,
+			expected: false,
+		},
+		{
+			name:     "tampered decoded event",
+			entry:    tamperedEvent,
+			expected: false,
+		},
+		{
+			name:     "decoded event with the wrong idtimestamp",
+			entry:    wrongIDTimestampEvent,
+			expected: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			massifContext := localLog.Massif(0)
+
+			verified, err := VerifyInclusion(test.entry, massifContext)
+			if !test.expected {
+				assert.ErrorIs(t, err, mmr.ErrVerifyInclusionFailed)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, test.expected, verified)
+
+			// the proof can also be got and verified separately
+			proof, err := EventProof(test.entry, massifContext)
+			require.NoError(t, err)
+
+			verified, err = VerifyProof(test.entry, proof, massifContext)
+			if !test.expected {
+				assert.ErrorIs(t, err, mmr.ErrVerifyInclusionFailed)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, test.expected, verified)
+		})
+	}
+}
+
+// TestVerifyProofInPeaks tests that inclusion is verified against only the peaks of a signed log state.
+func TestVerifyProofInPeaks(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+	appEntries := localLog.AppendEntries(5)
+	logState := localLog.Seal()
+
+	massifContext := localLog.Massif(0)
+
+	mmrEntry, err := appEntries[2].MMREntry(massifContext)
+	require.NoError(t, err)
+
+	proof, err := EventProof(appEntries[2], massifContext)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		mmrEntry []byte
+		mmrIndex uint64
+		peaks    [][]byte
+		err      error
+	}{
+		{
+			name:     "included",
+			mmrEntry: mmrEntry,
+			mmrIndex: appEntries[2].MMRIndex(),
+			peaks:    logState.Peaks,
+		},
+		{
+			name:     "tampered mmr entry",
+			mmrEntry: sha256.New().Sum(nil),
+			mmrIndex: appEntries[2].MMRIndex(),
+			peaks:    logState.Peaks,
+			err:      mmr.ErrVerifyInclusionFailed,
+		},
+		{
+			name:     "mmr index beyond the mmr size",
+			mmrEntry: mmrEntry,
+			mmrIndex: logState.MMRSize,
+			peaks:    logState.Peaks,
+			err:      ErrProofIndexBeyondSize,
+		},
+		{
+			name:     "missing peak",
+			mmrEntry: mmrEntry,
+			mmrIndex: appEntries[2].MMRIndex(),
+			peaks:    logState.Peaks[:1],
+			err:      ErrProofPeaksMismatch,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			verified, err := VerifyProofInPeaks(test.mmrEntry, test.mmrIndex, logState.MMRSize, proof, test.peaks)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				assert.False(t, verified)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, verified)
+		})
+	}
+}
+
+// TestEventProof_MMRSize tests that proofs can be bound to the size of a signed log state,
+// rather than the size of the massif when it was read.
+func TestEventProof_MMRSize(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+	appEntries := localLog.AppendEntries(5)
+	logState := localLog.Seal()
+
+	// the log grows after it is sealed
+	appEntries = append(appEntries, localLog.AppendEntries(2)...)
+
+	massifContext := localLog.Massif(0)
+	require.Greater(t, massifContext.RangeCount(), logState.MMRSize)
+
+	mmrEntry, err := appEntries[4].MMREntry(massifContext)
+	require.NoError(t, err)
+
+	// the leaf at mmr index 7 is a peak of the sealed log, but not of the log at the tip of the massif
+	//
+	// a proof against the tip of the massif does not lead to the sealed peaks
+	proof, err := EventProof(appEntries[4], massifContext)
+	require.NoError(t, err)
+
+	_, err = VerifyProofInPeaks(mmrEntry, appEntries[4].MMRIndex(), logState.MMRSize, proof, logState.Peaks)
+	assert.ErrorIs(t, err, mmr.ErrVerifyInclusionFailed)
+
+	// a proof bound to the sealed size does
+	proof, err = EventProof(appEntries[4], massifContext, WithVerifyMMRSize(logState.MMRSize))
+	require.NoError(t, err)
+
+	verified, err := VerifyProofInPeaks(mmrEntry, appEntries[4].MMRIndex(), logState.MMRSize, proof, logState.Peaks)
+	require.NoError(t, err)
+	assert.True(t, verified)
+
+	verified, err = VerifyProof(appEntries[4], proof, massifContext, WithVerifyMMRSize(logState.MMRSize))
+	require.NoError(t, err)
+	assert.True(t, verified)
+
+	// an app entry added after the seal can not be proven against it
+	_, err = EventProof(appEntries[5], massifContext, WithVerifyMMRSize(logState.MMRSize))
+	assert.ErrorIs(t, err, ErrProofIndexBeyondSize)
+
+	_, err = EventProof(appEntries[2], massifContext, WithVerifyMMRSize(massifContext.RangeCount()+1))
+	assert.ErrorIs(t, err, ErrProofMMRSizeBeyondMassif)
+}
+
+// TestVerifyList_MMRSize tests that list verification can be bound to the size of a signed log state.
+func TestVerifyList_MMRSize(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+	appEntries := localLog.AppendEntries(5)
+	logState := localLog.Seal()
+
+	appEntries = append(appEntries, localLog.AppendEntries(2)...)
+
+	results, err := VerifyListAll(
+		context.Background(), localLog.Reader(), appEntries[:5], WithVerifyMMRSize(logState.MMRSize))
+	require.NoError(t, err)
+
+	for _, result := range results {
+		assert.Equal(t, Included, result.AppEntryType)
+	}
+
+	_, err = VerifyListAll(
+		context.Background(), localLog.Reader(), appEntries, WithVerifyMMRSize(logState.MMRSize))
+	assert.ErrorIs(t, err, ErrProofIndexBeyondSize)
+}
+
+// TestVerifyList_MMRSizeAcrossMassifs tests that the leaves of earlier massifs are proven against
+// the size of a signed log state in a later massif, rather than the end of their own massif,
+// both walking the list in one go, and a massif at a time concurrently.
+func TestVerifyList_MMRSizeAcrossMassifs(t *testing.T) {
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	appEntries := localLog.AppendEntries(10) // 4 leaves per massif, so 3 massifs
+	logState := localLog.Seal()
+
+	appEntries = append(appEntries, localLog.AppendEntries(2)...)
+
+	tests := []struct {
+		name        string
+		concurrency int
+	}{
+		{
+			name:        "serial",
+			concurrency: 1,
+		},
+		{
+			name:        "concurrent",
+			concurrency: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			results, err := VerifyListAll(
+				context.Background(), localLog.Reader(), appEntries[:10],
+				WithVerifyMMRSize(logState.MMRSize), WithVerifyConcurrency(test.concurrency))
+			require.NoError(t, err)
+
+			require.Len(t, results, 10)
+			for _, result := range results {
+				assert.Equal(t, Included, result.AppEntryType)
+			}
+
+			_, err = VerifyListAll(
+				context.Background(), localLog.Reader(), appEntries,
+				WithVerifyMMRSize(logState.MMRSize), WithVerifyConcurrency(test.concurrency))
+			assert.ErrorIs(t, err, ErrProofIndexBeyondSize)
+		})
+	}
+}
+
+// TestListWalk_NodeStoreEvicted tests that a walk proving against an mmr size beyond its massif
+// only keeps the massifs after the one it is walking, not every massif it has walked past.
+func TestListWalk_NodeStoreEvicted(t *testing.T) {
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	appEntries := localLog.AppendEntries(14) // 4 leaves per massif, so 4 massifs
+	logState := localLog.Seal()
+
+	walk := newListWalk(
+		context.Background(), localLog.Reader(), 0, false, nil, VerifyOptions{mmrSize: logState.MMRSize}, nil)
+
+	addResult := func(result AppEntryResult) bool {
+		assert.Equal(t, Included, result.AppEntryType)
+		return true
+	}
+
+	for _, appEntry := range appEntries {
+
+		err := walk.next(appEntry, addResult)
+		require.NoError(t, err)
+
+		for massifIndex := range walk.nodeStore.massifContexts {
+			assert.Greater(t, massifIndex, uint64(walk.massifContext.Start.MassifIndex))
+		}
+	}
+
+	// the last massif holds every node of the signed log state
+	assert.Empty(t, walk.nodeStore.massifContexts)
+}
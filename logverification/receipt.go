@@ -0,0 +1,218 @@
+package logverification
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/datatrails/go-datatrails-common/azblob"
+	"github.com/datatrails/go-datatrails-common/cbor"
+	"github.com/datatrails/go-datatrails-common/cose"
+	"github.com/datatrails/go-datatrails-common/logger"
+	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/datatrails/go-datatrails-merklelog/mmr"
+)
+
+/**
+ * COSE receipts of inclusion, in the MMR profile (MMRIVER).
+ *
+ * A receipt is self contained, so a third party can verify an entry is included on the log
+ *  without any access to the log.
+ *
+ * Every seal carries a pre-signed receipt for each peak of the sealed log state, with the
+ *  peak as its detached payload. A receipt for an entry is the pre-signed receipt for the peak
+ *  the entry's inclusion proof leads to, with the inclusion proof attached in its unprotected header.
+ *
+ * The verifier recomputes the peak from the entry and the inclusion proof, and verifies the
+ *  signature of the receipt against it.
+ *
+ * A receipt for a leaf also carries the MMR Salt (extrabytes | idtimestamp) of the log entry in its
+ *  unprotected header, as the salt is not part of the app data, but is needed to recompute the leaf.
+ *  The salt does not need to be signed, a wrong salt recomputes a different leaf, which does not verify.
+ */
+
+const (
+	// ReceiptMMRSaltLabel is the unprotected header label of the MMR Salt of the leaf a receipt is for.
+	//
+	// NOTE: this is a private use label, as there is no registered label for the MMR Salt.
+	ReceiptMMRSaltLabel = int64(-65537)
+)
+
+var (
+	ErrReceiptPeakMissing = errors.New("the seal has no pre-signed receipt for the peak the inclusion proof leads to")
+	ErrReceiptNotSealed   = errors.New("the mmr index is not covered by the seal, so cannot have a receipt")
+)
+
+// NewInclusionReceipt creates a COSE receipt (MMRIVER), proving the inclusion of the mmr node at
+// the given mmr index, under the peaks of the given signed log state (seal).
+//
+// The given inclusion proof must be against the mmr size of the signed log state,
+// rather than the current size of the log.
+//
+// The given MMR Salt of the leaf at the mmr index is carried in the receipt, so the leaf can be
+// recomputed from the app data alone. It is nil if the mmr node is not a leaf.
+//
+// Returns the receipt serialized to CBOR.
+func NewInclusionReceipt(
+	signedState *cose.CoseSign1Message,
+	codec cbor.CBORCodec,
+	mmrIndex uint64,
+	proof [][]byte,
+	mmrSalt []byte,
+) ([]byte, error) {
+
+	logState, err := LogState(signedState, codec)
+	if err != nil {
+		return nil, fmt.Errorf("NewInclusionReceipt failed: unable to decode the log state: %w", err)
+	}
+
+	if mmrIndex >= logState.MMRSize {
+		return nil, fmt.Errorf("%w: mmr index %d, sealed mmr size %d", ErrReceiptNotSealed, mmrIndex, logState.MMRSize)
+	}
+
+	peakReceipts := massifs.MMRStateReceipts{}
+	err = codec.UnmarshalInto(signedState.Headers.RawUnprotected, &peakReceipts)
+	if err != nil {
+		return nil, fmt.Errorf("NewInclusionReceipt failed: unable to decode the peak receipts of the seal: %w", err)
+	}
+
+	peakIndex := mmr.PeakIndex(mmr.LeafCount(logState.MMRSize), len(proof))
+	if peakIndex >= len(peakReceipts.PeakReceipts) {
+		return nil, fmt.Errorf("%w: peak index %d, peak receipts %d",
+			ErrReceiptPeakMissing, peakIndex, len(peakReceipts.PeakReceipts))
+	}
+
+	receipt, err := cose.NewCoseSign1MessageFromCBOR(
+		peakReceipts.PeakReceipts[peakIndex], cose.WithDecOptions(massifs.CheckpointDecOptions()))
+	if err != nil {
+		return nil, fmt.Errorf("NewInclusionReceipt failed: unable to decode the pre-signed peak receipt: %w", err)
+	}
+
+	// the pre-signed peak receipts have empty unprotected headers,
+	//  the inclusion proof is attached there.
+	receipt.Headers.RawUnprotected = nil
+	receipt.Headers.Unprotected[massifs.VDSCoseReceiptProofsTag] = massifs.MMRiverVerifiableProofs{
+		InclusionProofs: []massifs.MMRiverInclusionProof{
+			{
+				Index:         mmrIndex,
+				InclusionPath: proof,
+			},
+		},
+	}
+
+	if mmrSalt != nil {
+		receipt.Headers.Unprotected[ReceiptMMRSaltLabel] = mmrSalt
+	}
+
+	return receipt.MarshalCBOR()
+}
+
+// Receipt creates a COSE receipt (MMRIVER), proving the inclusion of the mmr node
+// at the given mmr index on the given tenant's log, using the seal of the massif
+// the mmr node is in.
+//
+// The options argument can be the following:
+//
+//	WithVerifyMassifHeight - the massif height of the merklelog, instead of
+//	                         discovering it from the log.
+//
+// Returns the receipt serialized to CBOR.
+func Receipt(
+	ctx context.Context,
+	reader azblob.Reader,
+	tenantID string,
+	mmrIndex uint64,
+	options ...VerifyOption,
+) ([]byte, error) {
+
+	verifyOptions := ParseOptions(options...)
+
+	massifReader := massifs.NewMassifReader(logger.Sugar, reader)
+
+	massifContext, err := Massif(ctx, mmrIndex, &massifReader, tenantID, verifyOptions.massifHeight)
+	if err != nil {
+		return nil, fmt.Errorf("Receipt failed: unable to get the massif for mmr index %d: %w", mmrIndex, err)
+	}
+
+	codec, err := massifs.NewRootSignerCodec()
+	if err != nil {
+		return nil, err
+	}
+
+	signedState, err := SignedLogState(
+		ctx, reader, sha256.New(), codec, tenantID, uint64(massifContext.Start.MassifIndex))
+	if err != nil {
+		return nil, err
+	}
+
+	logState, err := LogState(signedState, codec)
+	if err != nil {
+		return nil, err
+	}
+
+	if mmrIndex >= logState.MMRSize {
+		return nil, fmt.Errorf("%w: mmr index %d, sealed mmr size %d", ErrReceiptNotSealed, mmrIndex, logState.MMRSize)
+	}
+
+	// NOTE: the massif may have grown since it was sealed, so the proof
+	//       is made against the sealed mmr size, not the size of the massif.
+	proof, err := mmr.InclusionProof(massifContext, logState.MMRSize-1, mmrIndex)
+	if err != nil {
+		return nil, fmt.Errorf("Receipt failed: unable to get the inclusion proof for mmr index %d: %w", mmrIndex, err)
+	}
+
+	// only a leaf has a log entry, so only a leaf has an mmr salt
+	var mmrSalt []byte
+	if mmr.IndexHeight(mmrIndex) == 0 {
+
+		trieEntry, err := massifContext.GetTrieEntry(mmrIndex)
+		if err != nil {
+			return nil, fmt.Errorf("Receipt failed: unable to get the trie entry for mmr index %d: %w", mmrIndex, err)
+		}
+
+		mmrSalt = app.NewMMRSalt(massifs.GetExtraBytes(trieEntry, 0, 0), massifs.GetIdtimestamp(trieEntry, 0, 0))
+	}
+
+	return NewInclusionReceipt(signedState, codec, mmrIndex, proof, mmrSalt)
+}
+
+// EventReceipt creates a COSE receipt (MMRIVER), proving the inclusion of the given event json,
+// as returned by either the assetsv2 or eventsv1 events API, on its tenant's log.
+//
+// The options argument can be the following:
+//
+//	WithTenantId - the tenantId of the merklelog, the event is expected
+//	               to be included on. E.g. the public tenant
+//	               for public events.
+//
+//	WithVerifyMassifHeight - the massif height of the merklelog, instead of
+//	                         discovering it from the log.
+//
+// Returns the receipt serialized to CBOR.
+func EventReceipt(
+	ctx context.Context,
+	eventJson []byte,
+	reader azblob.Reader,
+	options ...VerifyOption,
+) ([]byte, error) {
+
+	verifyOptions := ParseOptions(options...)
+
+	appEntry, err := app.NewAppEntryFromJSON(eventJson)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantID := verifyOptions.tenantId
+	if tenantID == "" {
+
+		tenantID, err = appEntry.LogTenant()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return Receipt(ctx, reader, tenantID, appEntry.MMRIndex(), options...)
+}
@@ -0,0 +1,80 @@
+package logverification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-common/cose"
+	"github.com/datatrails/go-datatrails-common/logger"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEventReceipt tests that event receipts verify against the event's leaf,
+// without any access to the log.
+func TestEventReceipt(t *testing.T) {
+	logger.New("TestEventReceipt")
+	defer logger.OnExit()
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+
+	events := localLog.AppendEvents(2)
+	eventsV1 := localLog.AppendEventsV1(1)
+	logState := localLog.Seal()
+
+	unsealedEvents := localLog.AppendEvents(1)
+
+	tests := []struct {
+		name      string
+		eventJson []byte
+		mmrIndex  uint64
+		err       error
+	}{
+		{
+			name:      "first event",
+			eventJson: events[0],
+			mmrIndex:  0,
+		},
+		{
+			name:      "last sealed event, from eventsv1",
+			eventJson: eventsV1[0],
+			mmrIndex:  3,
+		},
+		{
+			name:      "event after the seal",
+			eventJson: unsealedEvents[0],
+			err:       ErrReceiptNotSealed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			receiptCBOR, err := EventReceipt(context.Background(), test.eventJson, localLog.Reader())
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+
+			leaf, err := localLog.Massif(0).Get(test.mmrIndex)
+			require.NoError(t, err)
+
+			receipt, err := cose.NewCoseSign1MessageFromCBOR(
+				receiptCBOR, cose.WithDecOptions(massifs.CheckpointDecOptions()))
+			require.NoError(t, err)
+
+			verified, root, err := massifs.VerifySignedInclusionReceipt(context.Background(), receipt, leaf)
+			require.NoError(t, err)
+			assert.True(t, verified)
+			assert.Contains(t, logState.Peaks, root)
+
+			// the receipt must not verify for any other leaf
+			otherLeaf, err := localLog.Massif(0).Get(1)
+			require.NoError(t, err)
+
+			verified, _, _ = massifs.VerifySignedInclusionReceipt(context.Background(), receipt, otherLeaf)
+			assert.False(t, verified)
+		})
+	}
+}
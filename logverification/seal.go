@@ -2,21 +2,30 @@ package logverification
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"hash"
+	"time"
 
 	"github.com/datatrails/go-datatrails-common/azblob"
 	"github.com/datatrails/go-datatrails-common/cbor"
 	"github.com/datatrails/go-datatrails-common/cose"
 	"github.com/datatrails/go-datatrails-common/logger"
 	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/datatrails/go-datatrails-merklelog/massifs/snowflakeid"
 	"github.com/datatrails/go-datatrails-merklelog/mmr"
+	gocose "github.com/veraison/go-cose"
 )
 
 /**
  * log signed root (seal) utilities.
  */
 
+var (
+	ErrSealSignatureVerify = errors.New("the seal signature failed to verify")
+	ErrSealKeyIDMissing    = errors.New("the seal has no key id (kid) in its protected header")
+)
+
 // SignedLogState gets the signed state of the log for the massif at the given massif Index.
 func SignedLogState(
 	ctx context.Context,
@@ -61,6 +70,31 @@ func SignedLogState(
 	return signedState, nil
 }
 
+// MassifSealed returns true if the massif at the given massif index has a seal.
+//
+// Returns false, with no error, only if the seal does not exist. Any other failure to
+// read the seal is returned.
+func MassifSealed(
+	ctx context.Context,
+	reader azblob.Reader,
+	codec cbor.CBORCodec,
+	tenantID string,
+	massifIndex uint64,
+) (bool, error) {
+
+	sealReader := massifs.NewSignedRootReader(logger.Sugar, reader, codec)
+
+	_, _, err := sealReader.GetLatestMassifSignedRoot(ctx, tenantID, uint32(massifIndex))
+	if isBlobNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // LogState returns the unsigned state of the log, given a signed state.
 func LogState(signedState *cose.CoseSign1Message, codec cbor.CBORCodec) (*massifs.MMRState, error) {
 
@@ -72,3 +106,110 @@ func LogState(signedState *cose.CoseSign1Message, codec cbor.CBORCodec) (*massif
 
 	return unsignedState, nil
 }
+
+// SealKeyID gets the key id (kid) of the key the given seal is signed with.
+//
+// The kid is taken from the kid protected header if present, otherwise from the
+// confirmation key in the CWT claims protected header, which is where seals carry it.
+//
+// NOTE: only the kid is taken from the confirmation key, the public key in the
+// seal itself is never trusted.
+func SealKeyID(signedState *cose.CoseSign1Message) (string, error) {
+
+	_, ok := signedState.Headers.Protected[gocose.HeaderLabelKeyID]
+	if ok {
+		return signedState.KidFromProtectedHeader()
+	}
+
+	cwtClaims, err := signedState.CWTClaimsFromProtectedHeader()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrSealKeyIDMissing, err)
+	}
+
+	if cwtClaims.ConfirmationMethod == nil || len(cwtClaims.ConfirmationMethod.KeyID()) == 0 {
+		return "", ErrSealKeyIDMissing
+	}
+
+	return string(cwtClaims.ConfirmationMethod.KeyID()), nil
+}
+
+// VerifySignedLogState verifies the signature of the given signed state of the log,
+//
+//	against the trusted key with the key id (kid) in its protected header.
+//
+// The key must have been valid in the key store at the time the seal was made,
+// which is taken from the idtimestamp of the signed log state.
+//
+// The signed state is expected to have its payload recomputed from the log, as
+// SignedLogState does, so a verified signature also proves the peaks match the log.
+//
+// Returns the verified, unsigned state of the log.
+func VerifySignedLogState(
+	signedState *cose.CoseSign1Message,
+	codec cbor.CBORCodec,
+	keyStore KeyStore,
+) (*massifs.MMRState, error) {
+
+	kid, err := SealKeyID(signedState)
+	if err != nil {
+		return nil, fmt.Errorf("VerifySignedLogState failed: unable to get the kid of the seal: %w", err)
+	}
+
+	// NOTE: the log state is not trusted until the signature is verified below,
+	//       we only need its idtimestamp to select the key.
+	logState, err := LogState(signedState, codec)
+	if err != nil {
+		return nil, fmt.Errorf("VerifySignedLogState failed: unable to decode the log state: %w", err)
+	}
+
+	sealedAt, err := SealTime(logState)
+	if err != nil {
+		return nil, fmt.Errorf("VerifySignedLogState failed: unable to get the time of the seal: %w", err)
+	}
+
+	publicKey, err := keyStore.PublicKey(kid, sealedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = signedState.VerifyWithPublicKey(publicKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSealSignatureVerify, err)
+	}
+
+	return logState, nil
+}
+
+// SealTime gets the time the given log state was sealed, from its idtimestamp.
+func SealTime(logState *massifs.MMRState) (time.Time, error) {
+
+	unixMilli, err := snowflakeid.IDUnixMilli(logState.IDTimestamp, uint8(logState.CommitmentEpoch))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.UnixMilli(unixMilli).UTC(), nil
+}
+
+// VerifiedLogState gets the signed state of the log for the massif at the given massif index,
+//
+//	recomputing the peaks from the log, and verifies it against the given key store.
+//
+// Returns the verified, unsigned state of the log.
+func VerifiedLogState(
+	ctx context.Context,
+	reader azblob.Reader,
+	hasher hash.Hash,
+	codec cbor.CBORCodec,
+	tenantID string,
+	massifIndex uint64,
+	keyStore KeyStore,
+) (*massifs.MMRState, error) {
+
+	signedState, err := SignedLogState(ctx, reader, hasher, codec, tenantID, massifIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return VerifySignedLogState(signedState, codec, keyStore)
+}
@@ -0,0 +1,103 @@
+package logverification
+
+import (
+	"context"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-common/logger"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVerifySignedLogState tests:
+//
+// 1. a seal signed with a trusted key verifies, returning the log state.
+// 2. a seal signed with a kid that is not trusted returns a specific error.
+// 3. a seal that does not verify with the trusted key for its kid returns a specific error.
+// 4. a seal with a payload that does not match the signature returns a specific error.
+func TestVerifySignedLogState(t *testing.T) {
+	logger.New("TestVerifySignedLogState")
+	defer logger.OnExit()
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	localLog.AppendEntries(6)
+	expectedState := localLog.Seal()
+
+	otherKey := massifs.TestGenerateECKey(t, elliptic.P256())
+
+	tests := []struct {
+		name        string
+		trustedKeys TrustedKeys
+		tamper      bool
+		err         error
+	}{
+		{
+			name:        "positive",
+			trustedKeys: TrustedKeys{localLog.KeyID(): &localLog.signingKey.PublicKey},
+			err:         nil,
+		},
+		{
+			name:        "untrusted kid",
+			trustedKeys: TrustedKeys{"location:otherkey/version1": &localLog.signingKey.PublicKey},
+			err:         ErrUntrustedSealKey,
+		},
+		{
+			name:        "wrong key for kid",
+			trustedKeys: TrustedKeys{localLog.KeyID(): &otherKey.PublicKey},
+			err:         ErrSealSignatureVerify,
+		},
+		{
+			name:        "tampered payload",
+			trustedKeys: TrustedKeys{localLog.KeyID(): &localLog.signingKey.PublicKey},
+			tamper:      true,
+			err:         ErrSealSignatureVerify,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			signedState, err := SignedLogState(
+				context.Background(), localLog.Reader(), sha256.New(), localLog.codec, localLog.tenantID, 1)
+			require.NoError(t, err)
+
+			if test.tamper {
+				tamperedState := *expectedState
+				tamperedState.Peaks = [][]byte{sha256.New().Sum(nil)}
+
+				signedState.Payload, err = localLog.codec.MarshalCBOR(tamperedState)
+				require.NoError(t, err)
+			}
+
+			logState, err := VerifySignedLogState(signedState, localLog.codec, test.trustedKeys)
+
+			assert.ErrorIs(t, err, test.err)
+			if test.err != nil {
+				return
+			}
+
+			assert.Equal(t, expectedState.MMRSize, logState.MMRSize)
+			assert.Equal(t, expectedState.Peaks, logState.Peaks)
+		})
+	}
+}
+
+// TestVerifiedLogState tests that the state of a log can be read and verified in one call.
+func TestVerifiedLogState(t *testing.T) {
+	logger.New("TestVerifiedLogState")
+	defer logger.OnExit()
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	localLog.AppendEntries(3)
+	expectedState := localLog.Seal()
+
+	logState, err := VerifiedLogState(
+		context.Background(), localLog.Reader(), sha256.New(), localLog.codec, localLog.tenantID, 0,
+		TrustedKeys{localLog.KeyID(): &localLog.signingKey.PublicKey})
+	require.NoError(t, err)
+
+	assert.Equal(t, expectedState.MMRSize, logState.MMRSize)
+	assert.Equal(t, expectedState.Peaks, logState.Peaks)
+}
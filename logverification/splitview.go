@@ -0,0 +1,229 @@
+package logverification
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/datatrails/go-datatrails-common/azblob"
+	"github.com/datatrails/go-datatrails-common/cbor"
+	"github.com/datatrails/go-datatrails-common/cose"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+)
+
+/**
+ * Split view detection compares the seals of a tenant's log, read from two independent sources,
+ *  e.g. the primary log storage and a mirror of it.
+ *
+ * A log operator showing different histories to different readers is a split view. Each view
+ *  can be consistent on its own, so it can only be found by comparing the views.
+ *
+ * For each massif sealed in both sources, the two seals are verified, then:
+ *
+ *  1. Same size      - the peaks of both log states must be the same.
+ *  2. Different size - the larger log must be an append only extension of the smaller log,
+ *                      proven with a consistency proof built from the source of the larger log.
+ *
+ * A fork is reported with a compact evidence package, holding both seals and the failing consistency
+ *  proof, so that it can be checked by a third party without access to either source.
+ */
+
+var (
+	ErrSplitView        = errors.New("the log sources have signed log states that are not consistent with each other")
+	ErrSplitViewNoSeals = errors.New("the log sources have no sealed massif in common")
+)
+
+// SplitViewEvidence is the evidence of a fork between the logs of two sources.
+//
+// Both seals are verified, and have their peaks recomputed from their source's log, as returned by
+// SignedLogState. Seal A is the seal of the smaller log state, or of the first source if the log
+// states are the same size.
+type SplitViewEvidence struct {
+	TenantID    string `json:"tenant_id" cbor:"1,keyasint"`
+	MassifIndex uint64 `json:"massif_index" cbor:"2,keyasint"`
+
+	// SealA and SealB are the COSE Sign1 seals of the log states, serialized to CBOR.
+	SealA []byte `json:"seal_a" cbor:"3,keyasint"`
+	SealB []byte `json:"seal_b" cbor:"4,keyasint"`
+
+	// Proof is the consistency proof, from the log state of seal A to the log state of seal B,
+	// that fails to verify. It is nil if the log states are the same size, as the peaks differ.
+	Proof *ConsistencyProof `json:"proof,omitempty" cbor:"5,keyasint,omitempty"`
+}
+
+// SignedStates decodes both seals of the evidence.
+func (e *SplitViewEvidence) SignedStates() (*cose.CoseSign1Message, *cose.CoseSign1Message, error) {
+
+	signedStateA, err := cose.NewCoseSign1MessageFromCBOR(e.SealA, cose.WithDecOptions(massifs.CheckpointDecOptions()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signedStateB, err := cose.NewCoseSign1MessageFromCBOR(e.SealB, cose.WithDecOptions(massifs.CheckpointDecOptions()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return signedStateA, signedStateB, nil
+}
+
+// DetectSplitView compares the seal of each massif of the given tenant's log, from the given first
+// massif index onwards, read from both of the given sources, until a massif is not sealed in both.
+//
+// Only a massif that is not sealed in one of the sources ends the comparison. Any other failure
+// to read a seal, or a sealed massif, e.g. a sealed massif missing from a mirror, is returned.
+//
+// Returns the index of the last massif compared. If the seals of a massif are not consistent, returns
+// the evidence of the fork, with an error wrapping ErrSplitView.
+//
+// Returns ErrSplitViewNoSeals if the first massif is not sealed in both sources.
+//
+// The options argument can be the following:
+//
+//	WithVerifyMassifHeight - the massif height of the merklelog, instead of
+//	                         discovering it from the log.
+func DetectSplitView(
+	ctx context.Context,
+	readerA azblob.Reader,
+	readerB azblob.Reader,
+	codec cbor.CBORCodec,
+	keyStore KeyStore,
+	tenantID string,
+	firstMassifIndex uint64,
+	options ...VerifyOption,
+) (uint64, *SplitViewEvidence, error) {
+
+	for massifIndex := firstMassifIndex; ; massifIndex++ {
+
+		sealedA, err := MassifSealed(ctx, readerA, codec, tenantID, massifIndex)
+		if err != nil {
+			return massifIndex, nil, err
+		}
+
+		sealedB, err := MassifSealed(ctx, readerB, codec, tenantID, massifIndex)
+		if err != nil {
+			return massifIndex, nil, err
+		}
+
+		// only a massif that is not sealed yet, in either source, ends the comparison,
+		//  any other failure to read a seal or massif is returned.
+		if !sealedA || !sealedB {
+
+			if massifIndex == firstMassifIndex {
+				return 0, nil, fmt.Errorf("%w: massif %d", ErrSplitViewNoSeals, massifIndex)
+			}
+
+			return massifIndex - 1, nil, nil
+		}
+
+		evidence, err := CompareSignedStates(
+			ctx, readerA, readerB, codec, keyStore, tenantID, massifIndex, options...)
+		if err != nil {
+			return massifIndex, evidence, err
+		}
+	}
+}
+
+// CompareSignedStates compares the seal of the massif at the given massif index of the given tenant's
+// log, read from both of the given sources.
+//
+// Returns nil if both seals are verified, and their log states are consistent with each other.
+// Otherwise, if both seals are verified, returns the evidence of the fork, with an error wrapping ErrSplitView.
+//
+// The options argument can be the following:
+//
+//	WithVerifyMassifHeight - the massif height of the merklelog, instead of
+//	                         discovering it from the log.
+func CompareSignedStates(
+	ctx context.Context,
+	readerA azblob.Reader,
+	readerB azblob.Reader,
+	codec cbor.CBORCodec,
+	keyStore KeyStore,
+	tenantID string,
+	massifIndex uint64,
+	options ...VerifyOption,
+) (*SplitViewEvidence, error) {
+
+	signedStateA, err := SignedLogState(ctx, readerA, sha256.New(), codec, tenantID, massifIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	signedStateB, err := SignedLogState(ctx, readerB, sha256.New(), codec, tenantID, massifIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	// an unverified seal is not evidence of anything, so it is an error, rather than a fork
+	logStateA, err := VerifySignedLogState(signedStateA, codec, keyStore)
+	if err != nil {
+		return nil, err
+	}
+
+	logStateB, err := VerifySignedLogState(signedStateB, codec, keyStore)
+	if err != nil {
+		return nil, err
+	}
+
+	// order the log states so that A is the smaller, the proof is built from the source of the larger
+	readerLarger := readerB
+	if logStateA.MMRSize > logStateB.MMRSize {
+		logStateA, logStateB = logStateB, logStateA
+		signedStateA, signedStateB = signedStateB, signedStateA
+		readerLarger = readerA
+	}
+
+	var proof *ConsistencyProof
+	var forkErr error
+
+	if logStateA.MMRSize == logStateB.MMRSize {
+
+		if slices.EqualFunc(logStateA.Peaks, logStateB.Peaks, bytes.Equal) {
+			return nil, nil
+		}
+
+		forkErr = fmt.Errorf("%w: the peaks differ at mmr size %d", ErrSplitView, logStateA.MMRSize)
+
+	} else {
+
+		proof, err = NewConsistencyProof(
+			ctx, readerLarger, tenantID, logStateA.MMRSize, logStateB.MMRSize, options...)
+		if err != nil {
+			return nil, err
+		}
+
+		verified, err := VerifyConsistencyProof(proof, logStateA.Peaks, logStateB.Peaks)
+		if verified && err == nil {
+			return nil, nil
+		}
+
+		forkErr = fmt.Errorf("%w: mmr size %d to %d", ErrSplitView, logStateA.MMRSize, logStateB.MMRSize)
+		if err != nil {
+			forkErr = fmt.Errorf("%w: %w", forkErr, err)
+		}
+	}
+
+	sealA, err := signedStateA.MarshalCBOR()
+	if err != nil {
+		return nil, fmt.Errorf("CompareSignedStates failed: unable to cbor encode seal A: %w", err)
+	}
+
+	sealB, err := signedStateB.MarshalCBOR()
+	if err != nil {
+		return nil, fmt.Errorf("CompareSignedStates failed: unable to cbor encode seal B: %w", err)
+	}
+
+	evidence := &SplitViewEvidence{
+		TenantID:    tenantID,
+		MassifIndex: massifIndex,
+		SealA:       sealA,
+		SealB:       sealB,
+		Proof:       proof,
+	}
+
+	return evidence, forkErr
+}
@@ -0,0 +1,152 @@
+package logverification
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-common/logger"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/datatrails/go-datatrails-merklelog/mmr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDetectSplitView tests that a mirror lagging behind the primary log is consistent,
+// and that forks of the log, of the same and of different sizes, are detected.
+func TestDetectSplitView(t *testing.T) {
+	logger.New("TestDetectSplitView")
+	defer logger.OnExit()
+
+	primaryLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	keyStore := TrustedKeys{primaryLog.KeyID(): &primaryLog.signingKey.PublicKey}
+
+	// the mirror is a copy of the primary log, taken while massif 0 is partly full
+	primaryLog.AppendEntries(2)
+	primaryLog.Seal()
+
+	mirrorDir := t.TempDir()
+	err := os.CopyFS(mirrorDir, os.DirFS(primaryLog.rootDir))
+	require.NoError(t, err)
+
+	mirrorReader, err := NewFileSystemReader(mirrorDir)
+	require.NoError(t, err)
+
+	// massif 0 is filled, and massif 1 is started, on the primary only
+	primaryLog.AppendEntries(2)
+	logStateFull := primaryLog.Seal()
+
+	primaryLog.AppendEntries(2)
+	primaryLog.Seal()
+
+	// the fork has the same tenant, and is sealed with the same key, but has different entries
+	sameSizeFork := newTestLocalLog(t, testLocalLogMassifHeight)
+	sameSizeFork.signingKey = primaryLog.signingKey
+	sameSizeFork.AppendEntries(4)
+	sameSizeFork.Seal()
+
+	smallerFork := newTestLocalLog(t, testLocalLogMassifHeight)
+	smallerFork.signingKey = primaryLog.signingKey
+	smallerFork.AppendEntries(2)
+	smallerFork.Seal()
+
+	t.Run("lagging mirror", func(t *testing.T) {
+
+		lastIndex, evidence, err := DetectSplitView(
+			context.Background(), primaryLog.Reader(), mirrorReader, primaryLog.codec, keyStore, primaryLog.tenantID, 0)
+		require.NoError(t, err)
+		assert.Nil(t, evidence)
+
+		// massif 1 is not sealed on the mirror yet
+		assert.Equal(t, uint64(0), lastIndex)
+	})
+
+	t.Run("fork of the same size", func(t *testing.T) {
+
+		_, evidence, err := DetectSplitView(
+			context.Background(), primaryLog.Reader(), sameSizeFork.Reader(), primaryLog.codec, keyStore, primaryLog.tenantID, 0)
+		assert.ErrorIs(t, err, ErrSplitView)
+		require.NotNil(t, evidence)
+
+		assert.Equal(t, primaryLog.tenantID, evidence.TenantID)
+		assert.Equal(t, uint64(0), evidence.MassifIndex)
+		assert.Nil(t, evidence.Proof)
+
+		signedStateA, signedStateB, err := evidence.SignedStates()
+		require.NoError(t, err)
+
+		logStateA, err := VerifySignedLogState(signedStateA, primaryLog.codec, keyStore)
+		require.NoError(t, err)
+
+		logStateB, err := VerifySignedLogState(signedStateB, primaryLog.codec, keyStore)
+		require.NoError(t, err)
+
+		assert.Equal(t, logStateFull.MMRSize, logStateA.MMRSize)
+		assert.Equal(t, logStateFull.MMRSize, logStateB.MMRSize)
+		assert.NotEqual(t, logStateA.Peaks, logStateB.Peaks)
+	})
+
+	t.Run("fork of a smaller size", func(t *testing.T) {
+
+		_, evidence, err := DetectSplitView(
+			context.Background(), primaryLog.Reader(), smallerFork.Reader(), primaryLog.codec, keyStore, primaryLog.tenantID, 0)
+		assert.ErrorIs(t, err, ErrSplitView)
+		assert.ErrorIs(t, err, mmr.ErrConsistencyCheck)
+		require.NotNil(t, evidence)
+		require.NotNil(t, evidence.Proof)
+
+		// the evidence can be checked again, without access to either log
+		signedStateA, signedStateB, err := evidence.SignedStates()
+		require.NoError(t, err)
+
+		logStateA, err := VerifySignedLogState(signedStateA, primaryLog.codec, keyStore)
+		require.NoError(t, err)
+
+		logStateB, err := VerifySignedLogState(signedStateB, primaryLog.codec, keyStore)
+		require.NoError(t, err)
+
+		assert.Equal(t, logStateA.MMRSize, evidence.Proof.MMRSizeA)
+		assert.Equal(t, logStateB.MMRSize, evidence.Proof.MMRSizeB)
+
+		verified, err := VerifyConsistencyProof(evidence.Proof, logStateA.Peaks, logStateB.Peaks)
+		assert.ErrorIs(t, err, mmr.ErrConsistencyCheck)
+		assert.False(t, verified)
+	})
+
+	t.Run("sealed massif missing from the mirror", func(t *testing.T) {
+
+		// a mirror of the whole log, that has lost massif 1, but not its seal
+		truncatedDir := t.TempDir()
+		err := os.CopyFS(truncatedDir, os.DirFS(primaryLog.rootDir))
+		require.NoError(t, err)
+
+		err = os.Remove(filepath.Join(truncatedDir, filepath.FromSlash(massifs.TenantMassifBlobPath(primaryLog.tenantID, 1))))
+		require.NoError(t, err)
+
+		truncatedReader, err := NewFileSystemReader(truncatedDir)
+		require.NoError(t, err)
+
+		lastIndex, evidence, err := DetectSplitView(
+			context.Background(), primaryLog.Reader(), truncatedReader, primaryLog.codec, keyStore, primaryLog.tenantID, 0)
+		assert.ErrorIs(t, err, ErrFileSystemBlobMissing)
+		assert.Nil(t, evidence)
+		assert.Equal(t, uint64(1), lastIndex)
+	})
+
+	t.Run("untrusted seal", func(t *testing.T) {
+
+		_, evidence, err := DetectSplitView(
+			context.Background(), primaryLog.Reader(), smallerFork.Reader(), primaryLog.codec, TrustedKeys{}, primaryLog.tenantID, 0)
+		assert.ErrorIs(t, err, ErrUntrustedSealKey)
+		assert.Nil(t, evidence)
+	})
+
+	t.Run("no common seals", func(t *testing.T) {
+
+		_, evidence, err := DetectSplitView(
+			context.Background(), primaryLog.Reader(), mirrorReader, primaryLog.codec, keyStore, primaryLog.tenantID, 1)
+		assert.ErrorIs(t, err, ErrSplitViewNoSeals)
+		assert.Nil(t, evidence)
+	})
+}
@@ -2,8 +2,7 @@ package logverification
 
 import (
 	"errors"
-
-	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"fmt"
 )
 
 // Note: We need this logic to detect incomplete JSON unmarshalled into these types. This should
@@ -20,15 +19,16 @@ var (
 	ErrIdTimestampRequired      = errors.New("idtimestamp field is required and must be non-empty")
 )
 
-// Validate performs basic validation on the AppEntryGetter, ensuring that critical fields
+// Validate performs basic validation on the VerifiableAppEntry, ensuring that critical fields
 // are present.
-func Validate(appEntry app.AppEntry) error {
+func Validate(appEntry VerifiableAppEntry) error {
 	if appEntry.AppID() == "" {
 		return ErrNonEmptyAppIDRequired
 	}
 
-	if len(appEntry.LogID()) == 0 {
-		return ErrNonEmptyTenantIDRequired
+	_, err := appEntry.LogTenant()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNonEmptyTenantIDRequired, err)
 	}
 
 	return nil
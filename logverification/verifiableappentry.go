@@ -0,0 +1,35 @@
+package logverification
+
+/**
+ * Verifiable App Entry is what list and inclusion verification need from an app entry.
+ *
+ * app.AppEntry is the verifiable app entry for DataTrails apps, whose mmr entries are derived from
+ *  a domain and serialized bytes. Other apps built on the merklelog can supply their own type,
+ *  with their own derivation of the mmr entry.
+ */
+
+// VerifiableAppEntry is an app entry that can have its inclusion on the log verified.
+type VerifiableAppEntry interface {
+
+	// MMRIndex and MMREntry of the corresponding log entry, the mmr entry (leaf hash) is
+	// derived from the app data.
+	VerifiableMMREntry
+
+	// AppID gets the app id of the corresponding log entry.
+	AppID() string
+
+	// LogTenant gets the tenant identity of the log the corresponding log entry is on,
+	// e.g. "tenant/<uuid>".
+	LogTenant() (string, error)
+}
+
+// verifiableAppEntries gets the given app entries as a list of VerifiableAppEntry.
+func verifiableAppEntries[E VerifiableAppEntry](appEntries []E) []VerifiableAppEntry {
+
+	verifiable := make([]VerifiableAppEntry, len(appEntries))
+	for i, appEntry := range appEntries {
+		verifiable[i] = appEntry
+	}
+
+	return verifiable
+}
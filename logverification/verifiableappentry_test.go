@@ -0,0 +1,83 @@
+package logverification
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCustomAppEntry is an app entry for an app other than the DataTrails apps,
+// that derives its own mmr entry.
+type testCustomAppEntry struct {
+	appID    string
+	mmrIndex uint64
+	content  []byte
+}
+
+func (e testCustomAppEntry) AppID() string {
+	return e.appID
+}
+
+func (e testCustomAppEntry) MMRIndex() uint64 {
+	return e.mmrIndex
+}
+
+func (e testCustomAppEntry) LogTenant() (string, error) {
+	return testLocalLogTenantID, nil
+}
+
+// MMREntry is H( 0 | MMR Salt | content ), the same as the local log's entries.
+func (e testCustomAppEntry) MMREntry(massifContext *massifs.MassifContext) ([]byte, error) {
+
+	trieEntry, err := massifContext.GetTrieEntry(e.mmrIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	mmrSalt := app.NewMMRSalt(massifs.GetExtraBytes(trieEntry, 0, 0), massifs.GetIdtimestamp(trieEntry, 0, 0))
+
+	hasher := sha256.New()
+	hasher.Write([]byte{0})
+	hasher.Write(mmrSalt)
+	hasher.Write(e.content)
+
+	return hasher.Sum(nil), nil
+}
+
+// TestVerifyList_CustomAppEntry tests that an app can verify a list of its own app entry type.
+func TestVerifyList_CustomAppEntry(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+	appEntries := localLog.AppendEntries(4) // mmr indices 0, 1, 3, 4
+
+	customEntries := []testCustomAppEntry{}
+	for _, appEntry := range appEntries {
+		customEntries = append(customEntries, testCustomAppEntry{
+			appID:    appEntry.AppID(),
+			mmrIndex: appEntry.MMRIndex(),
+			content:  appEntry.SerializedBytes(),
+		})
+	}
+
+	// omit the app entry at mmr index 1 and tamper with the app entry at mmr index 4
+	customEntries = append(customEntries[:1], customEntries[2:]...)
+	customEntries[2].content = []byte("tampered")
+
+	results, err := VerifyListAll(context.Background(), localLog.Reader(), customEntries)
+	require.NoError(t, err)
+
+	expected := []AppEntryResult{
+		{AppEntryType: Included, AppID: customEntries[0].appID, MMRIndex: 0},
+		{AppEntryType: Omitted, MMRIndex: 1},
+		{AppEntryType: Included, AppID: customEntries[1].appID, MMRIndex: 3},
+		{AppEntryType: Excluded, AppID: customEntries[2].appID, MMRIndex: 4, Err: ErrAppEntryNotOnLeaf},
+		{AppEntryType: Omitted, MMRIndex: 4},
+	}
+
+	assert.Equal(t, expected, results)
+}
@@ -0,0 +1,195 @@
+package logverification
+
+import (
+	"context"
+	"encoding/binary"
+
+	"github.com/datatrails/go-datatrails-common/azblob"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+)
+
+/**
+ * Verification Report is a structured record of a list verification,
+ *  suitable for archiving as audit evidence.
+ *
+ * The report round trips through both JSON and CBOR, so that reports
+ *  from different runs can be stored and compared.
+ */
+
+// MassifSummary is a massif consulted during a verification.
+type MassifSummary struct {
+	MassifIndex uint32 `json:"massif_index" cbor:"1,keyasint"`
+
+	// RangeCount is the number of mmr nodes in the log, up to and including
+	//  the last node in the massif, at the time it was consulted.
+	RangeCount uint64 `json:"range_count" cbor:"2,keyasint"`
+}
+
+// AppEntryResultSummary is the serializable form of an AppEntryResult.
+type AppEntryResultSummary struct {
+	AppEntryType AppEntryType `json:"app_entry_type" cbor:"1,keyasint"`
+	AppID        string       `json:"app_id" cbor:"2,keyasint"`
+	MMRIndex     uint64       `json:"mmr_index" cbor:"3,keyasint"`
+
+	// Error is the reason an Excluded app entry is not included on the log.
+	//
+	// Empty for Included and Omitted results.
+	Error string `json:"error" cbor:"4,keyasint"`
+}
+
+// OmittedLeaf is a leaf on the log, within the leaf range, that has no app entry in the given list.
+type OmittedLeaf struct {
+	MMRIndex uint64 `json:"mmr_index" cbor:"1,keyasint"`
+
+	// IDTimestamp is the idtimestamp of the leaf, read from the log trie.
+	IDTimestamp uint64 `json:"id_timestamp" cbor:"2,keyasint"`
+}
+
+// VerificationReport records the outcome of verifying a list of app entries against the log.
+type VerificationReport struct {
+	TenantID string `json:"tenant_id" cbor:"1,keyasint"`
+
+	// LowestLeafIndex and HighestLeafIndex are the inclusive bounds of the leaf range checked.
+	LowestLeafIndex  uint64 `json:"lowest_leaf_index" cbor:"2,keyasint"`
+	HighestLeafIndex uint64 `json:"highest_leaf_index" cbor:"3,keyasint"`
+
+	// Massifs are the massifs consulted, lowest massif index first.
+	Massifs []MassifSummary `json:"massifs" cbor:"4,keyasint"`
+
+	// Results has one result per given app entry and omitted leaf, in the order they were verified.
+	Results []AppEntryResultSummary `json:"results" cbor:"5,keyasint"`
+
+	// OmittedLeaves are the omitted leaves, lowest mmr index first.
+	OmittedLeaves []OmittedLeaf `json:"omitted_leaves" cbor:"6,keyasint"`
+}
+
+// NewVerificationReport creates an empty verification report.
+func NewVerificationReport() *VerificationReport {
+	return &VerificationReport{
+		Massifs:       []MassifSummary{},
+		Results:       []AppEntryResultSummary{},
+		OmittedLeaves: []OmittedLeaf{},
+	}
+}
+
+// VerifyListReport verifies a given list of app entries against a range of leaves in the immutable merkle log,
+// in the same way as VerifyListAll, and returns a report of the verification.
+//
+// An error is only returned if the log could not be read.
+//
+// The options argument can be the following:
+//
+//	WithTenantId - the tenantId of the merklelog, the app entry is expected
+//	               to be included on. E.g. the public tenant
+//	               for public events.
+//
+//	WithVerifyMassifHeight - the massif height of the merklelog, instead of
+//	                         discovering it from the log.
+//
+//	WithVerifyConcurrency - the number of massifs to verify at the same time.
+//
+//	WithVerifyMMRSize - the size of the log to prove inclusion against, e.g. the size
+//	                    of a verified log state, instead of the size of each massif.
+func VerifyListReport[E VerifiableAppEntry](
+	ctx context.Context,
+	reader azblob.Reader,
+	appEntries []E,
+	options ...VerifyOption,
+) (*VerificationReport, error) {
+
+	report := NewVerificationReport()
+
+	results, err := verifyList(ctx, reader, verifiableAppEntries(appEntries), true, report, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+
+		summary := AppEntryResultSummary{
+			AppEntryType: result.AppEntryType,
+			AppID:        result.AppID,
+			MMRIndex:     result.MMRIndex,
+		}
+
+		if result.Err != nil {
+			summary.Error = result.Err.Error()
+		}
+
+		report.Results = append(report.Results, summary)
+	}
+
+	return report, nil
+}
+
+// addMassif records the given massif as consulted, if it has been read from the log.
+func (r *VerificationReport) addMassif(massifContext *massifs.MassifContext) {
+
+	// the massif context is empty until the first massif is read
+	if len(massifContext.Data) == 0 {
+		return
+	}
+
+	r.addMassifSummary(MassifSummary{
+		MassifIndex: massifContext.Start.MassifIndex,
+		RangeCount:  massifContext.RangeCount(),
+	})
+}
+
+// addMassifSummary records the given massif as consulted, replacing any earlier summary of the same massif.
+func (r *VerificationReport) addMassifSummary(summary MassifSummary) {
+
+	for i, massif := range r.Massifs {
+		if massif.MassifIndex == summary.MassifIndex {
+			r.Massifs[i] = summary
+			return
+		}
+	}
+
+	r.Massifs = append(r.Massifs, summary)
+}
+
+// addOmittedLeaf records the leaf at the given mmr index as omitted, reading its idtimestamp
+// from the massif that contains it.
+func (r *VerificationReport) addOmittedLeaf(
+	ctx context.Context,
+	massifReader MassifGetter,
+	massifContext *massifs.MassifContext,
+	leafMMRIndex uint64,
+	massifHeight uint8,
+) error {
+
+	err := UpdateMassifContext(ctx, massifReader, massifContext, leafMMRIndex, r.TenantID, massifHeight)
+	if err != nil {
+		return err
+	}
+
+	r.addMassif(massifContext)
+
+	trieEntry, err := massifContext.GetTrieEntry(leafMMRIndex)
+	if err != nil {
+		return err
+	}
+
+	r.OmittedLeaves = append(r.OmittedLeaves, OmittedLeaf{
+		MMRIndex:    leafMMRIndex,
+		IDTimestamp: binary.BigEndian.Uint64(massifs.GetIdtimestamp(trieEntry, 0, 0)),
+	})
+
+	return nil
+}
+
+// merge records the massifs consulted and omitted leaves of the given report, made for a later
+// part of the same leaf range, on this report.
+func (r *VerificationReport) merge(other *VerificationReport) {
+
+	if r.TenantID == "" {
+		r.TenantID = other.TenantID
+	}
+
+	for _, massif := range other.Massifs {
+		r.addMassifSummary(massif)
+	}
+
+	r.OmittedLeaves = append(r.OmittedLeaves, other.OmittedLeaves...)
+}
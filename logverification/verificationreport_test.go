@@ -0,0 +1,94 @@
+package logverification
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-common/cbor"
+	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVerifyListReport tests that the report records the tenant, leaf range, massifs,
+// per app entry results and omitted leaves of a list verification.
+func TestVerifyListReport(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+	appEntries := localLog.AppendEntries(5) // mmr indices 0, 1, 3, 4, 7
+
+	idTimestamp, err := appEntries[2].IDTimestamp(localLog.Massif(0))
+	require.NoError(t, err)
+
+	// omit the app entry at mmr index 3 and duplicate the app entry at mmr index 4
+	report, err := VerifyListReport(
+		context.Background(),
+		localLog.Reader(),
+		[]app.AppEntry{appEntries[0], appEntries[1], appEntries[3], appEntries[3], appEntries[4]},
+	)
+	require.NoError(t, err)
+
+	expected := &VerificationReport{
+		TenantID:         localLog.tenantID,
+		LowestLeafIndex:  0,
+		HighestLeafIndex: 4,
+		Massifs: []MassifSummary{
+			{MassifIndex: 0, RangeCount: 8},
+		},
+		Results: []AppEntryResultSummary{
+			{AppEntryType: Included, AppID: appEntries[0].AppID(), MMRIndex: 0},
+			{AppEntryType: Included, AppID: appEntries[1].AppID(), MMRIndex: 1},
+			{AppEntryType: Omitted, MMRIndex: 3},
+			{AppEntryType: Included, AppID: appEntries[3].AppID(), MMRIndex: 4},
+			{AppEntryType: Duplicated, AppID: appEntries[3].AppID(), MMRIndex: 4},
+			{AppEntryType: Included, AppID: appEntries[4].AppID(), MMRIndex: 7},
+		},
+		OmittedLeaves: []OmittedLeaf{
+			{MMRIndex: 3, IDTimestamp: binary.BigEndian.Uint64(idTimestamp)},
+		},
+	}
+
+	assert.Equal(t, expected, report)
+}
+
+// TestVerificationReport_RoundTrip tests that a report round trips through JSON and CBOR.
+func TestVerificationReport_RoundTrip(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+	appEntries := localLog.AppendEntries(4)
+
+	report, err := VerifyListReport(context.Background(), localLog.Reader(), []app.AppEntry{appEntries[0], appEntries[3]})
+	require.NoError(t, err)
+
+	// check we have something in every list
+	require.NotEmpty(t, report.Massifs)
+	require.NotEmpty(t, report.Results)
+	require.NotEmpty(t, report.OmittedLeaves)
+
+	t.Run("json", func(t *testing.T) {
+		encoded, err := json.Marshal(report)
+		require.NoError(t, err)
+
+		decoded := &VerificationReport{}
+		err = json.Unmarshal(encoded, decoded)
+		require.NoError(t, err)
+
+		assert.Equal(t, report, decoded)
+	})
+
+	t.Run("cbor", func(t *testing.T) {
+		codec, err := cbor.NewCBORCodec(cbor.NewDeterministicEncOpts(), cbor.NewDeterministicDecOpts())
+		require.NoError(t, err)
+
+		encoded, err := codec.MarshalCBOR(report)
+		require.NoError(t, err)
+
+		decoded := &VerificationReport{}
+		err = codec.UnmarshalInto(encoded, decoded)
+		require.NoError(t, err)
+
+		assert.Equal(t, report, decoded)
+	})
+}
@@ -16,10 +16,16 @@ import (
 // MMRState is an abstraction, but it is assumed that logStateA comes from a local, trusted copy of the data
 // rather than a fresh download from DataTrails.
 //
-// This function assumes the two log states are from the same massif.
+// The two log states can be in different massifs, any massifs needed for the proof,
+// as well as the ancestor peak stack of the last massif for log state B, are read from the log.
 //
 // NOTE: the log state's signatures are not verified in this function, it is expected that the signature verification
 // is done as a separate step to the consistency verification.
+//
+// The options argument can be the following:
+//
+//	WithVerifyMassifHeight - the massif height of the merklelog, instead of
+//	                         discovering it from the log.
 func VerifyConsistency(
 	ctx context.Context,
 	hasher hash.Hash,
@@ -27,8 +33,11 @@ func VerifyConsistency(
 	tenantID string,
 	logStateA *massifs.MMRState,
 	logStateB *massifs.MMRState,
+	options ...VerifyOption,
 ) (bool, error) {
 
+	verifyOptions := ParseOptions(options...)
+
 	if logStateA.Peaks == nil || logStateB.Peaks == nil {
 		return false, errors.New("VerifyConsistency failed: the roots for both log state A and log state B need to be set")
 	}
@@ -40,11 +49,15 @@ func VerifyConsistency(
 	massifReader := massifs.NewMassifReader(logger.Sugar, reader)
 
 	// last massif in the merkle log for log state B
-	massifContextB, err := Massif(logStateB.MMRSize-1, &massifReader, tenantID, DefaultMassifHeight)
+	massifContextB, err := Massif(ctx, logStateB.MMRSize-1, &massifReader, tenantID, verifyOptions.massifHeight)
 	if err != nil {
 		return false, fmt.Errorf("VerifyConsistency failed: unable to get the last massif for log state B: %w", err)
 	}
 
+	// nodes in earlier massifs are read as they are needed
+	nodeStore := NewMassifNodeStore(ctx, &massifReader, tenantID, verifyOptions.massifHeight)
+	nodeStore.AddMassif(massifContextB)
+
 	// We check a proof of consistency between logStateA and logStateB.
 	// This will be a proof that logStateB includes all elements from logStateA,
 	// and includes them in the same positions.
@@ -57,7 +70,7 @@ func VerifyConsistency(
 	// the children to verify unless their peaks also verify.  So we don't need
 	// to check every hash.
 
-	verified, _ /*peaksB*/, err := mmr.CheckConsistency(massifContextB, hasher, logStateA.MMRSize, logStateB.MMRSize, logStateA.Peaks)
+	verified, _ /*peaksB*/, err := mmr.CheckConsistency(nodeStore, hasher, logStateA.MMRSize, logStateB.MMRSize, logStateA.Peaks)
 
 	// A tampered node can not be proven unless the entire log is re-built.  If
 	// a log is re-built, any proof held by a relying party will not verify. And
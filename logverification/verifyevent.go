@@ -0,0 +1,250 @@
+package logverification
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/datatrails/go-datatrails-common/azblob"
+	"github.com/datatrails/go-datatrails-common/logger"
+	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/datatrails/go-datatrails-merklelog/mmr"
+)
+
+/**
+ * Verifies a single event end to end, in one call.
+ *
+ * An event is verified if its leaf is included under the peaks of the log,
+ *  and those peaks are committed to by a seal signed with a trusted key.
+ *
+ * The steps are:
+ *
+ *  1. Decode    - decode the assetsv2 or eventsv1 event json into its app entry.
+ *  2. Massif    - read the massif that contains the event's leaf.
+ *  3. Leaf      - check the event matches the leaf at the event's mmr index.
+ *  4. Seal      - read the seal for the massif, recomputing its peaks from the log.
+ *  5. Signature - verify the seal signature against the trusted key store.
+ *  6. Inclusion - prove the leaf is included under one of the sealed peaks.
+ */
+
+// VerifyEventStep is a step in the end to end verification of an event.
+type VerifyEventStep int
+
+const (
+	VerifyEventStepDecode VerifyEventStep = iota
+	VerifyEventStepMassif
+	VerifyEventStepLeaf
+	VerifyEventStepSeal
+	VerifyEventStepSignature
+	VerifyEventStepInclusion
+)
+
+var (
+	ErrEventNotSealed     = errors.New("the event is not yet covered by the seal of its massif")
+	ErrEventNotOnLeaf     = errors.New("the event does not correspond to the leaf at its mmr index")
+	ErrEventProofNotPeak  = errors.New("the event inclusion proof does not lead to a sealed peak")
+	ErrEventTenantInvalid = errors.New("the event tenant identity is not a valid tenant identity")
+)
+
+// String returns the name of the step.
+func (s VerifyEventStep) String() string {
+	switch s {
+	case VerifyEventStepDecode:
+		return "decode"
+	case VerifyEventStepMassif:
+		return "massif"
+	case VerifyEventStepLeaf:
+		return "leaf"
+	case VerifyEventStepSeal:
+		return "seal"
+	case VerifyEventStepSignature:
+		return "signature"
+	case VerifyEventStepInclusion:
+		return "inclusion"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// EventVerification is the result of verifying an event end to end.
+type EventVerification struct {
+
+	// Verified is true if every step passed.
+	Verified bool
+
+	// Step is the step that failed, or the last step if every step passed.
+	Step VerifyEventStep
+
+	// Err is the reason the step failed, nil if every step passed.
+	Err error
+
+	EventIdentity  string
+	TenantIdentity string
+	MMRIndex       uint64
+	MassifIndex    uint64
+
+	// LogState is the verified state of the log the event is included in.
+	LogState *massifs.MMRState
+
+	// Proof is the inclusion proof of the event's leaf, against the peaks of the log state.
+	Proof [][]byte
+}
+
+// fail records the given step as failed.
+func (ev *EventVerification) fail(step VerifyEventStep, err error) (*EventVerification, error) {
+	ev.Step = step
+	ev.Err = err
+	return ev, err
+}
+
+// VerifyEvent verifies the given event json, as returned by either the assetsv2 or eventsv1
+// events API, end to end.
+//
+// The event is verified if its leaf is included under the peaks committed to by the seal
+// of the massif it is in, and the seal is signed by a key in the given key store that was
+// valid at the time of the seal.
+//
+// The returned result is always non nil, recording the step that failed and why.
+// The returned error is the same as the result's Err.
+//
+// The options argument can be the following:
+//
+//	WithTenantId - the tenantId of the merklelog, the event is expected
+//	               to be included on. E.g. the public tenant
+//	               for public events.
+//
+//	WithVerifyMassifHeight - the massif height of the merklelog, instead of
+//	                         discovering it from the log.
+func VerifyEvent(
+	ctx context.Context,
+	eventJson []byte,
+	reader azblob.Reader,
+	keyStore KeyStore,
+	options ...VerifyOption,
+) (*EventVerification, error) {
+
+	verifyOptions := ParseOptions(options...)
+
+	result := &EventVerification{}
+
+	// 1. Decode
+	appEntry, err := app.NewAppEntryFromJSON(eventJson)
+	if errors.Is(err, app.ErrLogTenantInvalid) {
+		return result.fail(VerifyEventStepDecode, fmt.Errorf("%w: %w", ErrEventTenantInvalid, err))
+	}
+	if err != nil {
+		return result.fail(VerifyEventStepDecode, err)
+	}
+
+	result.EventIdentity = appEntry.AppID()
+	result.MMRIndex = appEntry.MMRIndex()
+
+	result.TenantIdentity, err = appEntry.LogTenant()
+	if err != nil {
+		return result.fail(VerifyEventStepDecode, err)
+	}
+
+	// the event is expected on a different log to its own tenant's, e.g. the public tenant's
+	if verifyOptions.tenantId != "" {
+
+		result.TenantIdentity = verifyOptions.tenantId
+
+		logID, err := tenantLogID(result.TenantIdentity)
+		if err != nil {
+			return result.fail(VerifyEventStepDecode, err)
+		}
+
+		appEntry = app.NewAppEntry(
+			appEntry.AppID(),
+			logID,
+			app.NewMMREntryFields(appEntry.Domain(), appEntry.SerializedBytes()),
+			appEntry.MMRIndex(),
+		)
+	}
+
+	// 2. Massif
+	massifReader := massifs.NewMassifReader(logger.Sugar, reader)
+
+	massifContext, err := Massif(
+		ctx, result.MMRIndex, &massifReader, result.TenantIdentity, verifyOptions.massifHeight)
+	if err != nil {
+		return result.fail(VerifyEventStepMassif, err)
+	}
+
+	result.MassifIndex = uint64(massifContext.Start.MassifIndex)
+
+	// 3. Leaf
+	if mmr.IndexHeight(result.MMRIndex) != 0 {
+		return result.fail(VerifyEventStepLeaf, ErrIntermediateNode)
+	}
+
+	leafValue, err := massifContext.Get(result.MMRIndex)
+	if err != nil {
+		return result.fail(VerifyEventStepLeaf, err)
+	}
+
+	mmrEntry, err := appEntry.MMREntry(massifContext)
+	if err != nil {
+		return result.fail(VerifyEventStepLeaf, err)
+	}
+
+	if !bytes.Equal(leafValue, mmrEntry) {
+		return result.fail(VerifyEventStepLeaf, ErrEventNotOnLeaf)
+	}
+
+	// 4. Seal
+	codec, err := massifs.NewRootSignerCodec()
+	if err != nil {
+		return result.fail(VerifyEventStepSeal, err)
+	}
+
+	signedState, err := SignedLogState(
+		ctx, reader, sha256.New(), codec, result.TenantIdentity, result.MassifIndex)
+	if err != nil {
+		return result.fail(VerifyEventStepSeal, err)
+	}
+
+	// 5. Signature
+	logState, err := VerifySignedLogState(signedState, codec, keyStore)
+	if err != nil {
+		return result.fail(VerifyEventStepSignature, err)
+	}
+
+	result.LogState = logState
+
+	// 6. Inclusion
+	if result.MMRIndex >= logState.MMRSize {
+		return result.fail(VerifyEventStepInclusion, ErrEventNotSealed)
+	}
+
+	proof, err := mmr.InclusionProof(massifContext, logState.MMRSize-1, result.MMRIndex)
+	if err != nil {
+		return result.fail(VerifyEventStepInclusion, err)
+	}
+
+	result.Proof = proof
+
+	_, err = VerifyProofInPeaks(mmrEntry, result.MMRIndex, logState.MMRSize, proof, logState.Peaks)
+	if err != nil {
+		return result.fail(VerifyEventStepInclusion, fmt.Errorf("%w: %w", ErrEventProofNotPeak, err))
+	}
+
+	result.Verified = true
+	result.Step = VerifyEventStepInclusion
+
+	return result, nil
+}
+
+// tenantLogID gets the log id of the given tenant identity, e.g. "tenant/<uuid>".
+func tenantLogID(tenantIdentity string) ([]byte, error) {
+
+	logID, err := app.LogID(tenantIdentity)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEventTenantInvalid, err)
+	}
+
+	return logID, nil
+}
@@ -0,0 +1,133 @@
+package logverification
+
+import (
+	"context"
+	"crypto/elliptic"
+	"strings"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-common/logger"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVerifyEvent tests that events verify end to end, and that
+// each failure is reported against the step that failed.
+func TestVerifyEvent(t *testing.T) {
+	logger.New("TestVerifyEvent")
+	defer logger.OnExit()
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+
+	events := localLog.AppendEvents(2)
+	eventsV1 := localLog.AppendEventsV1(1)
+	localLog.Seal()
+
+	unsealedEvents := localLog.AppendEvents(1)
+
+	trustedKeys := TrustedKeys{localLog.KeyID(): &localLog.signingKey.PublicKey}
+
+	otherKey := massifs.TestGenerateECKey(t, elliptic.P256())
+	untrustedKeys := TrustedKeys{localLog.KeyID(): &otherKey.PublicKey}
+
+	tamperedEvent := []byte(strings.Replace(string(events[1]), "RecordEvidence", "RecordEvidenceX", 1))
+	tamperedEventV1 := []byte(strings.Replace(string(eventsV1[0]), "event-attribute-0", "event-attribute-X", 1))
+
+	tests := []struct {
+		name      string
+		eventJson []byte
+		keyStore  KeyStore
+		options   []VerifyOption
+		verified  bool
+		step      VerifyEventStep
+		err       error
+	}{
+		{
+			name:      "first event",
+			eventJson: events[0],
+			keyStore:  trustedKeys,
+			verified:  true,
+			step:      VerifyEventStepInclusion,
+		},
+		{
+			name:      "last sealed assetsv2 event",
+			eventJson: events[1],
+			keyStore:  trustedKeys,
+			verified:  true,
+			step:      VerifyEventStepInclusion,
+		},
+		{
+			name:      "eventsv1 event",
+			eventJson: eventsV1[0],
+			keyStore:  trustedKeys,
+			verified:  true,
+			step:      VerifyEventStepInclusion,
+		},
+		{
+			name:      "given massif height",
+			eventJson: events[1],
+			keyStore:  trustedKeys,
+			options:   []VerifyOption{WithVerifyMassifHeight(testLocalLogMassifHeight)},
+			verified:  true,
+			step:      VerifyEventStepInclusion,
+		},
+		{
+			name:      "not json",
+			eventJson: []byte("not json"),
+			keyStore:  trustedKeys,
+			step:      VerifyEventStepDecode,
+		},
+		{
+			name:      "tampered event",
+			eventJson: tamperedEvent,
+			keyStore:  trustedKeys,
+			step:      VerifyEventStepLeaf,
+			err:       ErrEventNotOnLeaf,
+		},
+		{
+			name:      "tampered eventsv1 event",
+			eventJson: tamperedEventV1,
+			keyStore:  trustedKeys,
+			step:      VerifyEventStepLeaf,
+			err:       ErrEventNotOnLeaf,
+		},
+		{
+			name:      "untrusted key",
+			eventJson: events[0],
+			keyStore:  untrustedKeys,
+			step:      VerifyEventStepSignature,
+			err:       ErrSealSignatureVerify,
+		},
+		{
+			name:      "event after the seal",
+			eventJson: unsealedEvents[0],
+			keyStore:  trustedKeys,
+			step:      VerifyEventStepInclusion,
+			err:       ErrEventNotSealed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			result, err := VerifyEvent(
+				context.Background(), test.eventJson, localLog.Reader(), test.keyStore, test.options...)
+			require.NotNil(t, result)
+
+			assert.Equal(t, test.verified, result.Verified)
+			assert.Equal(t, test.step, result.Step, "failed at step %s", result.Step)
+			assert.Equal(t, err, result.Err)
+
+			if test.verified {
+				require.NoError(t, err)
+				assert.NotNil(t, result.LogState)
+				return
+			}
+
+			require.Error(t, err)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+			}
+		})
+	}
+}
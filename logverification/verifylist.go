@@ -2,13 +2,14 @@ package logverification
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"errors"
+	"fmt"
 	"hash"
 
 	"github.com/datatrails/go-datatrails-common/azblob"
 	"github.com/datatrails/go-datatrails-common/logger"
-	"github.com/datatrails/go-datatrails-logverification/logverification/app"
 	"github.com/datatrails/go-datatrails-merklelog/massifs"
 	"github.com/datatrails/go-datatrails-merklelog/mmr"
 )
@@ -67,6 +68,20 @@ import (
  * | leaf1          leaf2  leaf3 | leaf range from merklelog
  * |-----------------------------|
  *
+ * Duplicated Event:
+ *  An event in the given list, that is the same as the event before it in the list,
+ *    and so is included on the same leaf.
+ *
+ * Example of Duplicated event2:
+ *
+ * |-----------------------------|
+ * | event1 event2 event3 event4 | event list (lowest mmrIndex to highest)
+ * |-----------------------------|
+ *     ↓      ↓      ↓       ↓
+ * |-----------------------------|
+ * | leaf1  leaf1  leaf2  leaf3  | leaf range from merklelog
+ * |-----------------------------|
+ *
  * Omitted Event:
  *  An event on the immutable log,
  *    within the range of the list of events given,
@@ -98,11 +113,15 @@ const (
 
 	// Omitted is an app entry on the immutable log, that has not been given within an expected list of app entries.
 	Omitted
+
+	// Duplicated app entry is a given app entry that is the same as the app entry included
+	//  on an earlier leaf, e.g. from overlapping pages of a list events API call.
+	Duplicated
 )
 
 var (
 	ErrIntermediateNode          = errors.New("app entry references an intermediate node on the merkle log")
-	ErrDuplicateAppEntryMMRIndex = errors.New("app entry mmrIndex is the same as the previous event")
+	ErrDuplicateAppEntryMMRIndex = errors.New("app entry mmrIndex is the same as the previous event, but the app entry is different")
 	ErrAppEntryNotOnLeaf         = errors.New("app entry does not correspond to the event found on the leaf node")
 	ErrInclusionProofVerify      = errors.New("app entry failed to verify the inclusion proof on the merkle log")
 	ErrNotEnoughAppEntriesInList = errors.New("the number of app entries in the list is less than the number of leafs on the log")
@@ -110,7 +129,8 @@ var (
 
 /** VerifyList verifies a given list of app entries against a range of leaves in the immutable merkle log.
  *
- * The list of app entries for assetsv2 or eventsv1 is the json response from a datatrails list events API call.
+ * The list of app entries for assetsv2 or eventsv1 is the json response from a datatrails list events API call,
+ *  see app.NewAppEntriesFromJSON. Other apps on the merklelog can give their own VerifiableAppEntry type.
  *
  * The boundaries of the range of leaves are determined by the lowest and largest mmrIndex on the given list of app entries.
  * In the below example the event with the lowest mmrIndex matches leaf2 of the mmr,
@@ -139,7 +159,7 @@ var (
  *
  * If an app entry within the list of app entries is not present on the immutable merklelog
  *	 at the expected leaf index it is in tandem with, we call that an EXCLUDED event.
- *	 In the below example, entry2 is an EXCLUDED app entry. (Note: proof of exclusion using the trie index is not shown in this demo)
+ *	 In the below example, entry2 is an EXCLUDED app entry. (Note: see AttestIndexExclusion for exclusion using the trie index)
  *
  * |-----------------------------|
  * | entry1 entry2 entry3 entry4 | app entry list (lowest mmrIndex to highest)
@@ -162,86 +182,454 @@ var (
  * | leaf1  leaf2  leaf3  leaf4  | leaf range from merklelog
  * |-----------------------------|
  *
- * Returns the omitted app entry mmrIndexes.
+ * If an app entry within the list is the same as the app entry before it, e.g. because the list is made from
+ *  overlapping pages of a list events API call, we call that a DUPLICATED app entry.
+ *  A DUPLICATED app entry is recorded, and skipped over, and does not stop the verification.
+ *
+ * An app entry with the same mmrIndex as the app entry before it, that is NOT the app entry on the leaf,
+ *  is an EXCLUDED app entry.
+ *
+ * Returns the omitted app entry mmrIndexes, and the mmrIndexes of the DUPLICATED app entries,
+ *  once for each time an app entry is repeated.
  *
  * The options argument can be the following:
  *
  *   WithTenantId - the tenantId of the merklelog, the app entry is expected
  *                  to be included on. E.g. the public tenant
  *                  for public events.
+ *
+ *   WithVerifyMassifHeight - the massif height of the merklelog, instead of
+ *                            discovering it from the log.
+ *
+ *   WithVerifyConcurrency - the number of massifs to verify at the same time.
+ *                           The result is the same as verifying one leaf at a time.
+ *
+ *   WithVerifyMMRSize - the size of the log to prove inclusion against, e.g. the size
+ *                       of a verified log state, instead of the size of each massif.
  */
-func VerifyList(reader azblob.Reader, appEntries []app.AppEntry, options ...VerifyOption) ([]uint64, error) {
+func VerifyList[E VerifiableAppEntry](
+	ctx context.Context,
+	reader azblob.Reader,
+	appEntries []E,
+	options ...VerifyOption,
+) ([]uint64, []uint64, error) {
+
+	results, err := verifyList(ctx, reader, verifiableAppEntries(appEntries), false, nil, options...)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	verifyOptions := ParseOptions(options...)
+	omittedMMRIndices := []uint64{}
+	duplicatedMMRIndices := []uint64{}
+	for _, result := range results {
+		switch result.AppEntryType {
+		case Omitted:
+			omittedMMRIndices = append(omittedMMRIndices, result.MMRIndex)
+		case Duplicated:
+			duplicatedMMRIndices = append(duplicatedMMRIndices, result.MMRIndex)
+		}
+	}
 
-	hasher := sha256.New()
+	return omittedMMRIndices, duplicatedMMRIndices, nil
+}
 
-	massifContext := massifs.MassifContext{}
-	omittedMMRIndices := []uint64{}
+// AppEntryResult is the outcome of verifying a single app entry, or a single omitted leaf,
+// as part of a list of app entries.
+type AppEntryResult struct {
+
+	// AppEntryType is Included, Excluded, Omitted or Duplicated.
+	AppEntryType AppEntryType
+
+	// AppID is the app id of the given app entry.
+	//
+	// Empty for Omitted results, as there is no given app entry for an omitted leaf.
+	AppID string
+
+	// MMRIndex is the mmr index of the given app entry,
+	//  or the mmr index of the leaf for Omitted results.
+	//
+	// For Duplicated results, this is the mmr index of the earlier leaf the app entry is on.
+	MMRIndex uint64
+
+	// Err is the reason an Excluded app entry is not included on the log, one of:
+	//
+	//   ErrIntermediateNode, ErrDuplicateAppEntryMMRIndex, ErrAppEntryNotOnLeaf or ErrInclusionProofVerify.
+	Err error
+}
+
+/** VerifyListAll verifies a given list of app entries against a range of leaves in the immutable merkle log,
+ *    in the same way as VerifyList, but reports on every app entry and every leaf in the range.
+ *
+ * Unlike VerifyList, an EXCLUDED app entry does not stop the verification.
+ *  The excluded app entry is recorded along with the reason it is excluded, and the next app entry in the list
+ *  is checked against the same leaf.
+ *
+ * Leaves in the range that have no app entry in the list are recorded as OMITTED.
+ *
+ * App entries that are the same as the app entry before them in the list are recorded as DUPLICATED.
+ *
+ * Returns a result for every given app entry and every omitted leaf, in the order they are walked.
+ *
+ * An error is only returned if the log could not be read, in which case no results are returned.
+ *
+ * The options argument can be the following:
+ *
+ *   WithTenantId - the tenantId of the merklelog, the app entry is expected
+ *                  to be included on. E.g. the public tenant
+ *                  for public events.
+ *
+ *   WithVerifyMassifHeight - the massif height of the merklelog, instead of
+ *                            discovering it from the log.
+ *
+ *   WithVerifyConcurrency - the number of massifs to verify at the same time.
+ *                           The result is the same as verifying one leaf at a time.
+ *
+ *   WithVerifyMMRSize - the size of the log to prove inclusion against, e.g. the size
+ *                       of a verified log state, instead of the size of each massif.
+ */
+func VerifyListAll[E VerifiableAppEntry](
+	ctx context.Context,
+	reader azblob.Reader,
+	appEntries []E,
+	options ...VerifyOption,
+) ([]AppEntryResult, error) {
+	return verifyList(ctx, reader, verifiableAppEntries(appEntries), true, nil, options...)
+}
+
+// verifyList walks the range of leaves and the list of app entries in tandem.
+//
+// If reportAll is false, the walk stops at the first EXCLUDED app entry, returning its reason as the error.
+//
+// If reportAll is true, EXCLUDED app entries are recorded and the walk carries on with the next
+// app entry in the list at the same leaf index.
+//
+// If report is not nil, the tenant, leaf range, massifs consulted and omitted leaves are recorded on it.
+func verifyList(
+	ctx context.Context,
+	reader azblob.Reader,
+	appEntries []VerifiableAppEntry,
+	reportAll bool,
+	report *VerificationReport,
+	options ...VerifyOption,
+) ([]AppEntryResult, error) {
+
+	verifyOptions := ParseOptions(options...)
+
+	if len(appEntries) == 0 {
+		return []AppEntryResult{}, nil
+	}
 
 	lowestLeafIndex, highestLeafIndex := LeafRange(appEntries)
 
-	massifReader := massifs.NewMassifReader(logger.Sugar, reader)
+	if report != nil {
+		report.LowestLeafIndex = lowestLeafIndex
+		report.HighestLeafIndex = highestLeafIndex
+	}
 
-	appEntryIndex := 0
+	if verifyOptions.concurrency > 1 {
+		return verifyListConcurrent(
+			ctx, reader, appEntries, lowestLeafIndex, highestLeafIndex, reportAll, report, verifyOptions)
+	}
 
-	for leafIndex := lowestLeafIndex; leafIndex <= highestLeafIndex; leafIndex += 1 {
+	return verifyLeafRange(
+		ctx, reader, appEntries, lowestLeafIndex, highestLeafIndex, true, reportAll, report, verifyOptions, nil)
+}
 
-		if appEntryIndex >= len(appEntries) {
-			return nil, ErrNotEnoughAppEntriesInList
+// verifyLeafRange walks the given range of leaves and the given list of app entries in tandem,
+// as described by verifyList.
+//
+// If lastRange is false, there are more leaves after the range that the list carries on to,
+// so running out of app entries within the range means the remaining leaves are OMITTED.
+//
+// The given node store, if not nil, is shared with the walks of other ranges.
+func verifyLeafRange(
+	ctx context.Context,
+	reader azblob.Reader,
+	appEntries []VerifiableAppEntry,
+	lowestLeafIndex uint64,
+	highestLeafIndex uint64,
+	lastRange bool,
+	reportAll bool,
+	report *VerificationReport,
+	verifyOptions VerifyOptions,
+	nodeStore *MassifNodeStore,
+) ([]AppEntryResult, error) {
+
+	walk := newListWalk(ctx, reader, lowestLeafIndex, reportAll, report, verifyOptions, nodeStore)
+
+	results := []AppEntryResult{}
+	addResult := func(result AppEntryResult) bool {
+		results = append(results, result)
+		return true
+	}
+
+	for _, appEntry := range appEntries {
+		err := walk.next(appEntry, addResult)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		appEntry := appEntries[appEntryIndex]
+	// the leaves left in the range have no app entries
+	if walk.leafIndex <= highestLeafIndex && !reportAll && lastRange {
+		return nil, ErrNotEnoughAppEntriesInList
+	}
 
-		// ensure we set the tenantId if
-		//  if it passed in as an optional argument
-		tenantId := verifyOptions.tenantId
-		if tenantId == "" {
+	err := walk.omitTo(highestLeafIndex, addResult)
+	if err != nil {
+		return nil, err
+	}
 
-			// otherwise set it to the event tenantID
-			var err error
-			tenantId, err = appEntry.LogTenant()
-			if err != nil {
-				return nil, err
-			}
+	return results, nil
+}
+
+// listWalk is the state of walking the leaves of the log and a list of app entries in tandem.
+type listWalk struct {
+	ctx context.Context
+
+	hasher        hash.Hash
+	massifReader  massifs.MassifReader
+	massifContext massifs.MassifContext
+
+	// leafIndex is the next leaf to check an app entry against.
+	leafIndex uint64
+
+	// nodeStore serves the nodes of later massifs, to prove leaves in earlier massifs
+	//  against an mmr size beyond them. It is created once the tenant is known, unless given.
+	nodeStore *MassifNodeStore
+
+	// ownsNodeStore is set if the walk created its node store, so evicts the massifs
+	//  it has walked past from it. A given node store is evicted by its owner.
+	ownsNodeStore bool
+
+	reportAll     bool
+	report        *VerificationReport
+	verifyOptions VerifyOptions
+
+	// stopped is set once addResult asks for the walk to stop, after which
+	//  nothing more is walked, or read from the log.
+	stopped bool
+}
+
+// newListWalk creates a walk of the leaves of the log, starting at the given leaf index.
+//
+// The given node store, if not nil, is shared with other walks, e.g. of the other massifs
+// in a concurrent verification, otherwise the walk creates its own.
+func newListWalk(
+	ctx context.Context,
+	reader azblob.Reader,
+	leafIndex uint64,
+	reportAll bool,
+	report *VerificationReport,
+	verifyOptions VerifyOptions,
+	nodeStore *MassifNodeStore,
+) *listWalk {
+	return &listWalk{
+		ctx:           ctx,
+		hasher:        sha256.New(),
+		massifReader:  massifs.NewMassifReader(logger.Sugar, reader),
+		massifContext: massifs.MassifContext{},
+		leafIndex:     leafIndex,
+		reportAll:     reportAll,
+		report:        report,
+		verifyOptions: verifyOptions,
+		nodeStore:     nodeStore,
+	}
+}
+
+// next walks the given app entry, the next in the list, against the leaves,
+// calling addResult with the result of the app entry and any leaves OMITTED before it.
+//
+// If addResult returns false, the walk stops straight away, and no error is returned.
+//
+// If reportAll is false, an EXCLUDED app entry returns the reason it is excluded as the error.
+func (w *listWalk) next(appEntry VerifiableAppEntry, addResult func(AppEntryResult) bool) error {
+
+	if w.stopped {
+		return nil
+	}
+
+	// ensure we set the tenantId if
+	//  if it passed in as an optional argument
+	tenantId := w.verifyOptions.tenantId
+	if tenantId == "" {
+
+		// otherwise set it to the event tenantID
+		var err error
+		tenantId, err = appEntry.LogTenant()
+		if err != nil {
+			return err
+		}
+
+	}
+
+	if w.report != nil && w.report.TenantID == "" {
+		w.report.TenantID = tenantId
+	}
 
+	if w.nodeStore == nil {
+		w.nodeStore = NewMassifNodeStore(w.ctx, &w.massifReader, tenantId, w.verifyOptions.massifHeight)
+		w.ownsNodeStore = true
+	}
+
+	for {
+
+		if w.stopped {
+			return nil
 		}
 
-		appEntryType, err := VerifyAppEntryInList(hasher, leafIndex, appEntry, massifReader, &massifContext, tenantId)
+		// stop promptly if the caller has cancelled, as not every leaf reads from storage
+		err := w.ctx.Err()
 		if err != nil {
+			return err
+		}
 
-			// NOTE: for now fail at the first sign of an EXCLUDED event.
-			//       If the event is EXCLUDED, we could log that like omitted and carry
-			//       on with the next event in the list at the same leaf index.
-			return nil, err
+		appEntryType, err := VerifyAppEntryInList(
+			w.ctx, w.hasher, w.leafIndex, appEntry, w.massifReader, &w.massifContext, tenantId,
+			w.verifyOptions.massifHeight, w.verifyOptions.mmrSize, w.nodeStore)
+		if w.report != nil {
+			w.report.addMassif(&w.massifContext)
+		}
+
+		// the leaves left to walk are in this massif or later, so their proofs
+		//  never need the massifs up to this one from the node store.
+		if w.ownsNodeStore {
+			w.nodeStore.EvictTo(uint64(w.massifContext.Start.MassifIndex))
+		}
+		if appEntryType == Excluded && w.reportAll {
+
+			// record the EXCLUDED app entry and carry on with the next
+			//  app entry in the list at the same leaf index.
+			w.add(addResult, AppEntryResult{
+				AppEntryType: Excluded,
+				AppID:        appEntry.AppID(),
+				MMRIndex:     appEntry.MMRIndex(),
+				Err:          err,
+			})
+
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// a DUPLICATED app entry is on an earlier leaf, so we check the
+		//  next app entry in the list against the same leaf.
+		if appEntryType == Duplicated {
+			w.add(addResult, AppEntryResult{
+				AppEntryType: Duplicated,
+				AppID:        appEntry.AppID(),
+				MMRIndex:     appEntry.MMRIndex(),
+			})
+
+			return nil
 		}
 
 		// if the event is OMITTED add the leaf to the omitted list
 		if appEntryType == Omitted {
-			omittedMMRIndices = append(omittedMMRIndices, mmr.MMRIndex(leafIndex))
+
+			err = w.omit(addResult)
+			if err != nil {
+				return err
+			}
 
 			// as the event is still the lowest mmrIndex we check this event
 			//  against the next leaf
 			continue
 		}
 
-		appEntryIndex += 1
+		w.add(addResult, AppEntryResult{
+			AppEntryType: Included,
+			AppID:        appEntry.AppID(),
+			MMRIndex:     appEntry.MMRIndex(),
+		})
+
+		w.leafIndex += 1
+
+		return nil
+	}
+}
+
+// omitTo records every leaf from the next leaf, up to and including the given leaf index, as OMITTED.
+//
+// If addResult returns false, the walk stops straight away, and no error is returned.
+func (w *listWalk) omitTo(leafIndex uint64, addResult func(AppEntryResult) bool) error {
+
+	for w.leafIndex <= leafIndex && !w.stopped {
+
+		err := w.ctx.Err()
+		if err != nil {
+			return err
+		}
+
+		err = w.omit(addResult)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
+// omit records the next leaf as OMITTED, and moves on to the leaf after it.
+func (w *listWalk) omit(addResult func(AppEntryResult) bool) error {
+
+	leafMMRIndex := mmr.MMRIndex(w.leafIndex)
+
+	w.add(addResult, AppEntryResult{
+		AppEntryType: Omitted,
+		MMRIndex:     leafMMRIndex,
+	})
+	if w.stopped {
+		return nil
 	}
 
-	return omittedMMRIndices, nil
+	if w.report != nil {
+		err := w.report.addOmittedLeaf(
+			w.ctx, &w.massifReader, &w.massifContext, leafMMRIndex, w.verifyOptions.massifHeight)
+		if err != nil {
+			return err
+		}
+	}
+
+	w.leafIndex += 1
+
+	return nil
+}
+
+// add calls addResult with the given result, stopping the walk if addResult returns false.
+func (w *listWalk) add(addResult func(AppEntryResult) bool, result AppEntryResult) {
+	if !addResult(result) {
+		w.stopped = true
+	}
 }
 
 // VerifyAppEntryInList takes the next leaf in the list of leaves and the next app entry in the list of app entries
 //
 //	and verifies that the app entry is in that leaf position.
+//
+// If the given massif height is 0, the massif height is discovered from the log.
+//
+// The inclusion proof is against the given mmr size, e.g. the size of a verified log state.
+// If the given mmr size is 0, the proof is against the end of the massif.
+//
+// An mmr size beyond the end of the massif needs nodes from the later massifs, which are read
+// through the given node store. The node store can be shared across calls, so each later massif
+// is only read once. If it is nil, a node store is created for the call.
+//
+// NOTE: only the size of the log is bound by the given mmr size, the inclusion proof is verified
+// against the peaks of the massifs, not the peaks of a signed log state.
 func VerifyAppEntryInList(
+	ctx context.Context,
 	hasher hash.Hash,
 	leafIndex uint64,
-	appEntry app.AppEntry,
+	appEntry VerifiableAppEntry,
 	reader massifs.MassifReader,
 	massifContext *massifs.MassifContext,
 	tenantID string,
+	massifHeight uint8,
+	mmrSize uint64,
+	nodeStore *MassifNodeStore,
 ) (AppEntryType, error) {
 
 	hasher.Reset()
@@ -267,10 +655,10 @@ func VerifyAppEntryInList(
 	// This means the mmr index of the app entry matches the previous leaf node.
 	//
 	// This can occur because one of the following:
-	//   1. The event is a duplicate of the previous app entry in the list.
-	//   2. The event is not included on the previous leaf, but says it is.
+	//   1. The event is a duplicate of the previous app entry in the list, it is DUPLICATED.
+	//   2. The event is not included on the previous leaf, but says it is, it is EXCLUDED.
 	//
-	// In both cases we say the app entry is not on the merkle log, it is EXCLUDED.
+	// We tell the two apart by checking the app entry against the leaf at its own mmr index.
 	//
 	// Example:
 	//  Entry mmrIndex: 10
@@ -287,14 +675,12 @@ func VerifyAppEntryInList(
 	//  / \   / \   / \   /  \   /  \
 	// 0   1 3   4 7   8 10  11 15  16 <- Leaf Nodes
 	//
-	// NOTE: in the future we may mark a duplicated event as DUPLICATED instead of EXCLUDED.
-	//
 	// NOTE: we can make the above assumptions because:
 	//       1. the event mmrIndex is the next in the list of app entries,
 	//          so the previous app entry was included on the previous leaf node.
 	//       2. we have already checked that the app entry mmrIndex is not an intermediate node.
 	if appEntryMMRIndex < leafMMRIndex {
-		return Excluded, ErrDuplicateAppEntryMMRIndex
+		return verifyDuplicateAppEntry(ctx, appEntry, reader, massifContext, tenantID, massifHeight)
 	}
 
 	// When the next app entry in the list of app entries has an mmrindex GREATER THAN the next leaf in the range of leaves.
@@ -333,7 +719,7 @@ func VerifyAppEntryInList(
 	// We now do an inclusion proof on the app entry, to prove that the app entry is included at the leaf node.
 
 	// Ensure we're using the correct massif for the current leaf
-	err := UpdateMassifContext(&reader, massifContext, leafMMRIndex, tenantID, DefaultMassifHeight)
+	err := UpdateMassifContext(ctx, &reader, massifContext, leafMMRIndex, tenantID, massifHeight)
 	if err != nil {
 		return Unknown, err
 	}
@@ -358,15 +744,34 @@ func VerifyAppEntryInList(
 
 	// Now we know that the app entry is the app entry stored on the leaf node,
 	// we can do an inclusion proof of the leaf node on the merkle log.
-	mmrSize := massifContext.RangeCount()
+	if mmrSize == 0 {
+		mmrSize = massifContext.RangeCount()
+	}
+
+	if leafMMRIndex >= mmrSize {
+		return Unknown, fmt.Errorf("%w: mmr index %d, mmr size %d", ErrProofIndexBeyondSize, leafMMRIndex, mmrSize)
+	}
+
+	// the massif holds every node of a log up to its own size, beyond that,
+	//  the proof needs the nodes of the later massifs.
+	var store nodeGetter = massifContext
 
-	inclusionProof, err := mmr.InclusionProof(massifContext, mmrSize-1, leafMMRIndex)
+	if mmrSize > massifContext.RangeCount() {
+
+		if nodeStore == nil {
+			nodeStore = NewMassifNodeStore(ctx, &reader, tenantID, massifHeight)
+		}
+
+		store = &laterMassifsNodeGetter{massifContext: massifContext, nodeStore: nodeStore}
+	}
+
+	inclusionProof, err := mmr.InclusionProof(store, mmrSize-1, leafMMRIndex)
 	if err != nil {
 		return Unknown, err
 	}
 
 	verified, err := mmr.VerifyInclusion(
-		massifContext, hasher, mmrSize, mmrEntry, leafMMRIndex, inclusionProof)
+		store, hasher, mmrSize, mmrEntry, leafMMRIndex, inclusionProof)
 	if !verified || errors.Is(err, mmr.ErrVerifyInclusionFailed) {
 		return Excluded, ErrInclusionProofVerify
 	}
@@ -377,3 +782,44 @@ func VerifyAppEntryInList(
 	return Included, nil
 
 }
+
+// verifyDuplicateAppEntry verifies an app entry that has the same mmrIndex as an earlier leaf,
+// that the previous app entry in the list was included on.
+//
+// If the app entry is the app entry on the earlier leaf, it is DUPLICATED, otherwise it is EXCLUDED.
+//
+// NOTE: the inclusion of the earlier leaf was proven when the previous app entry was verified,
+// so only the leaf itself is checked here.
+func verifyDuplicateAppEntry(
+	ctx context.Context,
+	appEntry VerifiableAppEntry,
+	reader massifs.MassifReader,
+	massifContext *massifs.MassifContext,
+	tenantID string,
+	massifHeight uint8,
+) (AppEntryType, error) {
+
+	appEntryMMRIndex := appEntry.MMRIndex()
+
+	// the earlier leaf may be at the end of the previous massif
+	err := UpdateMassifContext(ctx, &reader, massifContext, appEntryMMRIndex, tenantID, massifHeight)
+	if err != nil {
+		return Unknown, err
+	}
+
+	leafMMREntry, err := massifContext.Get(appEntryMMRIndex)
+	if err != nil {
+		return Unknown, err
+	}
+
+	mmrEntry, err := appEntry.MMREntry(massifContext)
+	if err != nil {
+		return Unknown, err
+	}
+
+	if !bytes.Equal(leafMMREntry, mmrEntry) {
+		return Excluded, ErrDuplicateAppEntryMMRIndex
+	}
+
+	return Duplicated, nil
+}
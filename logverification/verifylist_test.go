@@ -3,6 +3,7 @@
 package logverification
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -84,7 +85,7 @@ func TestVerifyListIntegration(t *testing.T) {
 	// following:
 	//   1. Detect any events in the log that were omitted from the list of events we have.
 	//   2. Prove the inclusion of all events in our list against the merkle log.
-	omittedIndices, err := VerifyList(testContext.Storer, events)
+	omittedIndices, _, err := VerifyList(context.Background(), testContext.Storer, events)
 	require.Nil(t, err)
 
 	// If there were omittedIndices in our events, then the events are incomplete within that time
@@ -110,7 +111,7 @@ func TestVerifyList_OmmittedEventReturned(t *testing.T) {
 	)
 	trimmedGeneratedEvents := append(generatedEvents[:3], generatedEvents[4:]...)
 	events := protoEventsToVerifiableEvents(t, trimmedGeneratedEvents)
-	omittedIndices, err := VerifyList(testContext.Storer, events)
+	omittedIndices, _, err := VerifyList(context.Background(), testContext.Storer, events)
 
 	require.NoError(t, err)
 	require.Len(t, omittedIndices, 1)
@@ -133,7 +134,7 @@ func TestVerifyList_MultipleOmittedEventsReturned(t *testing.T) {
 	)
 	trimmedGeneratedEvents := append(generatedEvents[:3], generatedEvents[5:]...)
 	events := protoEventsToVerifiableEvents(t, trimmedGeneratedEvents)
-	omittedIndices, err := VerifyList(testContext.Storer, events)
+	omittedIndices, _, err := VerifyList(context.Background(), testContext.Storer, events)
 
 	require.NoError(t, err)
 	require.Len(t, omittedIndices, 2)
@@ -156,7 +157,7 @@ func TestVerifyList_TamperedEventContent_ShouldError(t *testing.T) {
 	// Modify one of the logged events
 	generatedEvents[5].EventAttributes["additional"] = attribute.NewStringAttribute("foobar")
 	events := protoEventsToVerifiableEvents(t, generatedEvents)
-	_, err := VerifyList(testContext.Storer, events)
+	_, _, err := VerifyList(context.Background(), testContext.Storer, events)
 
 	require.ErrorIs(t, err, ErrAppEntryNotOnLeaf)
 }
@@ -180,7 +181,7 @@ func TestVerifyList_IntermediateNode_ShouldError(t *testing.T) {
 	eventsWithExtra = append(eventsWithExtra, generatedEvents[2:]...)
 
 	events := protoEventsToVerifiableEvents(t, eventsWithExtra)
-	_, err := VerifyList(testContext.Storer, events)
+	_, _, err := VerifyList(context.Background(), testContext.Storer, events)
 
 	require.ErrorIs(t, err, ErrIntermediateNode)
 }
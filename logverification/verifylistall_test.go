@@ -0,0 +1,205 @@
+package logverification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVerifyListAll tests that every app entry and every omitted leaf is reported,
+// carrying on past excluded app entries.
+func TestVerifyListAll(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+	appEntries := localLog.AppendEntries(6) // mmr indices 0, 1, 3, 4, 7, 8
+
+	tamperedEntry := app.NewAppEntry(
+		appEntries[2].AppID(),
+		appEntries[2].LogID(),
+		app.NewMMREntryFields(0, []byte(`{"identity":"tampered"}`)),
+		appEntries[2].MMRIndex(),
+	)
+
+	intermediateEntry := app.NewAppEntry(
+		appEntries[1].AppID(),
+		appEntries[1].LogID(),
+		app.NewMMREntryFields(0, appEntries[1].SerializedBytes()),
+		2,
+	)
+
+	// a different app entry claiming the leaf already used by appEntries[1]
+	claimingEntry := app.NewAppEntry(
+		appEntries[2].AppID(),
+		appEntries[2].LogID(),
+		app.NewMMREntryFields(0, appEntries[2].SerializedBytes()),
+		appEntries[1].MMRIndex(),
+	)
+
+	included := func(appEntry app.AppEntry) AppEntryResult {
+		return AppEntryResult{AppEntryType: Included, AppID: appEntry.AppID(), MMRIndex: appEntry.MMRIndex()}
+	}
+
+	tests := []struct {
+		name       string
+		appEntries []app.AppEntry
+		expected   []AppEntryResult
+	}{
+		{
+			name:       "all included",
+			appEntries: appEntries,
+			expected: []AppEntryResult{
+				included(appEntries[0]),
+				included(appEntries[1]),
+				included(appEntries[2]),
+				included(appEntries[3]),
+				included(appEntries[4]),
+				included(appEntries[5]),
+			},
+		},
+		{
+			name: "omitted",
+			appEntries: []app.AppEntry{
+				appEntries[0], appEntries[1], appEntries[3], appEntries[5],
+			},
+			expected: []AppEntryResult{
+				included(appEntries[0]),
+				included(appEntries[1]),
+				{AppEntryType: Omitted, MMRIndex: 3},
+				included(appEntries[3]),
+				{AppEntryType: Omitted, MMRIndex: 7},
+				included(appEntries[5]),
+			},
+		},
+		{
+			name: "tampered app entry, carries on to the end",
+			appEntries: []app.AppEntry{
+				appEntries[0], appEntries[1], *tamperedEntry, appEntries[3], appEntries[4], appEntries[5],
+			},
+			expected: []AppEntryResult{
+				included(appEntries[0]),
+				included(appEntries[1]),
+				{AppEntryType: Excluded, AppID: tamperedEntry.AppID(), MMRIndex: 3, Err: ErrAppEntryNotOnLeaf},
+				{AppEntryType: Omitted, MMRIndex: 3},
+				included(appEntries[3]),
+				included(appEntries[4]),
+				included(appEntries[5]),
+			},
+		},
+		{
+			name: "duplicate app entries, including a trailing duplicate",
+			appEntries: []app.AppEntry{
+				appEntries[0], appEntries[1], appEntries[1], appEntries[2], appEntries[3], appEntries[4], appEntries[5], appEntries[5],
+			},
+			expected: []AppEntryResult{
+				included(appEntries[0]),
+				included(appEntries[1]),
+				{AppEntryType: Duplicated, AppID: appEntries[1].AppID(), MMRIndex: 1},
+				included(appEntries[2]),
+				included(appEntries[3]),
+				included(appEntries[4]),
+				included(appEntries[5]),
+				{AppEntryType: Duplicated, AppID: appEntries[5].AppID(), MMRIndex: 8},
+			},
+		},
+		{
+			name: "different app entry claiming an already used leaf",
+			appEntries: []app.AppEntry{
+				appEntries[0], appEntries[1], *claimingEntry, appEntries[2], appEntries[3], appEntries[4], appEntries[5],
+			},
+			expected: []AppEntryResult{
+				included(appEntries[0]),
+				included(appEntries[1]),
+				{AppEntryType: Excluded, AppID: claimingEntry.AppID(), MMRIndex: 1, Err: ErrDuplicateAppEntryMMRIndex},
+				included(appEntries[2]),
+				included(appEntries[3]),
+				included(appEntries[4]),
+				included(appEntries[5]),
+			},
+		},
+		{
+			name: "intermediate node",
+			appEntries: []app.AppEntry{
+				appEntries[0], appEntries[1], *intermediateEntry, appEntries[2], appEntries[3], appEntries[4], appEntries[5],
+			},
+			expected: []AppEntryResult{
+				included(appEntries[0]),
+				included(appEntries[1]),
+				{AppEntryType: Excluded, AppID: intermediateEntry.AppID(), MMRIndex: 2, Err: ErrIntermediateNode},
+				included(appEntries[2]),
+				included(appEntries[3]),
+				included(appEntries[4]),
+				included(appEntries[5]),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := VerifyListAll(context.Background(), localLog.Reader(), test.appEntries)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+// TestVerifyList_Excluded tests that VerifyList still fails at the first excluded app entry.
+func TestVerifyList_Excluded(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+	appEntries := localLog.AppendEntries(4)
+
+	// a different app entry claiming the leaf already used by appEntries[1]
+	claimingEntry := app.NewAppEntry(
+		appEntries[2].AppID(),
+		appEntries[2].LogID(),
+		app.NewMMREntryFields(0, appEntries[2].SerializedBytes()),
+		appEntries[1].MMRIndex(),
+	)
+
+	omittedMMRIndices, duplicatedMMRIndices, err := VerifyList(
+		context.Background(), localLog.Reader(),
+		[]app.AppEntry{appEntries[0], appEntries[1], *claimingEntry, appEntries[2], appEntries[3]},
+	)
+
+	assert.ErrorIs(t, err, ErrDuplicateAppEntryMMRIndex)
+	assert.Nil(t, omittedMMRIndices)
+	assert.Nil(t, duplicatedMMRIndices)
+}
+
+// TestVerifyList_Duplicated tests that VerifyList carries on past duplicated app entries,
+// e.g. from overlapping pages of a list events API call.
+func TestVerifyList_Duplicated(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+	appEntries := localLog.AppendEntries(5) // mmr indices 0, 1, 3, 4, 7
+
+	// overlapping pages, with a trailing duplicate
+	pages := []app.AppEntry{
+		appEntries[0], appEntries[1], appEntries[1], appEntries[2], appEntries[4], appEntries[4],
+	}
+
+	omittedMMRIndices, duplicatedMMRIndices, err := VerifyList(context.Background(), localLog.Reader(), pages)
+	require.NoError(t, err)
+
+	assert.Equal(t, []uint64{4}, omittedMMRIndices)
+	assert.Equal(t, []uint64{1, 7}, duplicatedMMRIndices)
+}
+
+// TestVerifyList_Cancelled tests that a cancelled context stops the verification.
+func TestVerifyList_Cancelled(t *testing.T) {
+
+	localLog := newTestLocalLog(t, DefaultMassifHeight)
+	appEntries := localLog.AppendEntries(4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := VerifyList(ctx, localLog.Reader(), appEntries)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = VerifyListAll(ctx, localLog.Reader(), appEntries)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,223 @@
+package logverification
+
+import (
+	"context"
+	"sync"
+
+	"github.com/datatrails/go-datatrails-common/azblob"
+	"github.com/datatrails/go-datatrails-common/logger"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/datatrails/go-datatrails-merklelog/mmr"
+)
+
+/**
+ * Concurrent list verification.
+ *
+ * The leaf range is split into segments, one per massif, and each segment is walked in the same way
+ *  as the serial walk, with its own massif context, in a bounded pool of workers. The later massifs
+ *  needed to prove against an mmr size beyond a segment's massif are shared by every segment.
+ *
+ * The list of app entries is split alongside the leaves. A segment gets every app entry with an mmrIndex
+ *  from the first leaf of its massif, up to but not including the first leaf of the next massif.
+ *
+ * |----------------------|----------------------|
+ * | entry1 entry2 entry3 | entry4        entry6 | app entry list (lowest mmrIndex to highest)
+ * |----------------------|----------------------|
+ *     ↓      ↓      ↓    |   ↓             ↓
+ * |----------------------|----------------------|
+ * | leaf1  leaf2  leaf3  | leaf4  leaf5  leaf6  | leaf range from merklelog
+ * |----------------------|----------------------|
+ *        massif 0        |        massif 1
+ *
+ * This is where the serial walk is when it first reaches the massif, as every app entry before the first
+ *  leaf of a massif is checked before that leaf is. So the results of the segments, merged in massif order,
+ *  are the same as the results of the serial walk.
+ */
+
+// listSegment is the part of a list verification for a single massif.
+type listSegment struct {
+	appEntries       []VerifiableAppEntry
+	lowestLeafIndex  uint64
+	highestLeafIndex uint64
+
+	results []AppEntryResult
+	report  *VerificationReport
+	err     error
+}
+
+// verifyListConcurrent verifies the list of app entries against the given range of leaves,
+// with each massif in the range verified by one of a pool of workers.
+//
+// The results, and the report if not nil, are the same as verifying the whole range in one walk.
+func verifyListConcurrent(
+	ctx context.Context,
+	reader azblob.Reader,
+	appEntries []VerifiableAppEntry,
+	lowestLeafIndex uint64,
+	highestLeafIndex uint64,
+	reportAll bool,
+	report *VerificationReport,
+	verifyOptions VerifyOptions,
+) ([]AppEntryResult, error) {
+
+	tenantID := verifyOptions.tenantId
+	if tenantID == "" {
+
+		var err error
+		tenantID, err = appEntries[0].LogTenant()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	massifReader := massifs.NewMassifReader(logger.Sugar, reader)
+
+	// the massif height is needed up front to split the leaf range
+	if verifyOptions.massifHeight == 0 {
+
+		var err error
+		verifyOptions.massifHeight, err = MassifHeight(ctx, &massifReader, tenantID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	segments := listSegments(appEntries, lowestLeafIndex, highestLeafIndex, verifyOptions.massifHeight)
+
+	// the segments share the later massifs their proofs need, so each is read once
+	nodeStore := NewMassifNodeStore(ctx, &massifReader, tenantID, verifyOptions.massifHeight)
+
+	// an error in a segment stops the segments after it, but not the ones before it,
+	//  so the error returned is the first one the serial walk would find.
+	cancels := make([]context.CancelFunc, len(segments))
+	segmentCtxs := make([]context.Context, len(segments))
+	for i := range segments {
+		segmentCtxs[i], cancels[i] = context.WithCancel(ctx)
+	}
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+
+	var cancelMtx sync.Mutex
+	cancelAfter := func(segmentIndex int) {
+		cancelMtx.Lock()
+		defer cancelMtx.Unlock()
+
+		for _, cancel := range cancels[segmentIndex+1:] {
+			cancel()
+		}
+	}
+
+	// a segment only needs the massifs after its own from the node store, so once every segment
+	//  before a segment is finished, the massifs up to that segment's massif are no longer needed.
+	var finishedMtx sync.Mutex
+	finished := make([]bool, len(segments))
+	finish := func(segmentIndex int) {
+		finishedMtx.Lock()
+		defer finishedMtx.Unlock()
+
+		finished[segmentIndex] = true
+
+		for i, segment := range segments {
+			if !finished[i] {
+				nodeStore.EvictTo(massifs.MassifIndexFromMMRIndex(
+					verifyOptions.massifHeight, mmr.MMRIndex(segment.lowestLeafIndex)))
+				return
+			}
+		}
+	}
+
+	workers := make(chan struct{}, verifyOptions.concurrency)
+	var wg sync.WaitGroup
+
+	for i, segment := range segments {
+
+		if report != nil {
+			segment.report = NewVerificationReport()
+			segment.report.TenantID = tenantID
+		}
+
+		wg.Add(1)
+		workers <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			segment.results, segment.err = verifyLeafRange(
+				segmentCtxs[i], reader, segment.appEntries,
+				segment.lowestLeafIndex, segment.highestLeafIndex, i == len(segments)-1,
+				reportAll, segment.report, verifyOptions, nodeStore)
+			if segment.err != nil {
+				cancelAfter(i)
+			}
+
+			finish(i)
+		}()
+	}
+
+	wg.Wait()
+
+	results := []AppEntryResult{}
+
+	for _, segment := range segments {
+
+		if segment.err != nil {
+			return nil, segment.err
+		}
+
+		results = append(results, segment.results...)
+
+		if report != nil {
+			report.merge(segment.report)
+		}
+	}
+
+	return results, nil
+}
+
+// listSegments splits the given range of leaves, and the list of app entries, into one segment per massif.
+//
+// Every massif in the range has a segment, even if it has no app entries.
+func listSegments(
+	appEntries []VerifiableAppEntry,
+	lowestLeafIndex uint64,
+	highestLeafIndex uint64,
+	massifHeight uint8,
+) []*listSegment {
+
+	leavesPerMassif := uint64(1) << (massifHeight - 1)
+
+	segments := []*listSegment{}
+	appEntryIndex := 0
+
+	for firstLeafIndex := lowestLeafIndex; firstLeafIndex <= highestLeafIndex; {
+
+		// the first leaf of the next massif
+		nextMassifLeafIndex := (firstLeafIndex/leavesPerMassif + 1) * leavesPerMassif
+		nextMassifMMRIndex := mmr.MMRIndex(nextMassifLeafIndex)
+
+		segment := &listSegment{
+			lowestLeafIndex:  firstLeafIndex,
+			highestLeafIndex: min(nextMassifLeafIndex-1, highestLeafIndex),
+		}
+
+		lastSegment := nextMassifLeafIndex > highestLeafIndex
+
+		// the last segment takes any trailing app entries
+		segmentEnd := appEntryIndex
+		for segmentEnd < len(appEntries) && (lastSegment || appEntries[segmentEnd].MMRIndex() < nextMassifMMRIndex) {
+			segmentEnd++
+		}
+
+		segment.appEntries = appEntries[appEntryIndex:segmentEnd]
+		appEntryIndex = segmentEnd
+
+		segments = append(segments, segment)
+		firstLeafIndex = nextMassifLeafIndex
+	}
+
+	return segments
+}
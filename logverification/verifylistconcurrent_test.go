@@ -0,0 +1,85 @@
+package logverification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVerifyListConcurrent tests that verifying a list one massif per worker gives
+// the same results as verifying it one leaf at a time.
+func TestVerifyListConcurrent(t *testing.T) {
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	appEntries := localLog.AppendEntries(14) // 4 massifs of 4 leaves
+
+	tamperedEntry := app.NewAppEntry(
+		appEntries[5].AppID(),
+		appEntries[5].LogID(),
+		app.NewMMREntryFields(0, []byte(`{"identity":"tampered"}`)),
+		appEntries[5].MMRIndex(),
+	)
+
+	tests := []struct {
+		name       string
+		appEntries []app.AppEntry
+	}{
+		{
+			name:       "all included",
+			appEntries: appEntries,
+		},
+		{
+			name: "whole massif omitted",
+			appEntries: []app.AppEntry{
+				appEntries[1], appEntries[2], appEntries[3], appEntries[8], appEntries[13],
+			},
+		},
+		{
+			name: "duplicated across a massif boundary",
+			appEntries: []app.AppEntry{
+				appEntries[2], appEntries[3], appEntries[3], appEntries[4], appEntries[7], appEntries[7],
+			},
+		},
+		{
+			name: "tampered app entry",
+			appEntries: []app.AppEntry{
+				appEntries[4], *tamperedEntry, appEntries[6], appEntries[12],
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			expected, err := VerifyListAll(context.Background(), localLog.Reader(), test.appEntries)
+			require.NoError(t, err)
+
+			actual, err := VerifyListAll(
+				context.Background(), localLog.Reader(), test.appEntries, WithVerifyConcurrency(3))
+			require.NoError(t, err)
+
+			assert.Equal(t, expected, actual)
+
+			expectedReport, err := VerifyListReport(context.Background(), localLog.Reader(), test.appEntries)
+			require.NoError(t, err)
+
+			actualReport, err := VerifyListReport(
+				context.Background(), localLog.Reader(), test.appEntries, WithVerifyConcurrency(3))
+			require.NoError(t, err)
+
+			assert.Equal(t, expectedReport, actualReport)
+
+			expectedOmitted, expectedDuplicated, expectedErr := VerifyList(
+				context.Background(), localLog.Reader(), test.appEntries)
+
+			actualOmitted, actualDuplicated, actualErr := VerifyList(
+				context.Background(), localLog.Reader(), test.appEntries, WithVerifyConcurrency(3))
+
+			assert.Equal(t, expectedErr, actualErr)
+			assert.Equal(t, expectedOmitted, actualOmitted)
+			assert.Equal(t, expectedDuplicated, actualDuplicated)
+		})
+	}
+}
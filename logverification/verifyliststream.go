@@ -0,0 +1,258 @@
+package logverification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/datatrails/go-datatrails-common/azblob"
+	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"github.com/datatrails/go-datatrails-merklelog/mmr"
+)
+
+/**
+ * Streaming list verification, for lists of app entries too large to hold in memory.
+ *
+ * The app entries are verified one at a time, as they are read, in the same way as VerifyListAll.
+ *  Only the app entry being verified and the massif it is in are held in memory, along with,
+ *  when proving against an mmr size beyond that massif, the later massifs up to that size.
+ *
+ * As the list is never sorted, the app entries must already be in mmrIndex order,
+ *  which is the order the list events API returns them in.
+ */
+
+var (
+	ErrAppEntryOutOfOrder  = errors.New("app entry mmrIndex is lower than the previous app entry, the list must be in mmrIndex order")
+	ErrEventsJSONMalformed = errors.New("the events json is not a list events API response")
+)
+
+// VerifyListSeq verifies the given sequence of app entries, in mmrIndex order, against a range of leaves
+// in the immutable merkle log, in the same way as VerifyListAll.
+//
+// The results are yielded as they are found, in the order they are walked, with a nil error.
+// If the log could not be read, or the app entries are not in mmrIndex order, the error is yielded
+// with an empty result and the sequence stops.
+//
+// The options argument can be the following:
+//
+//	WithTenantId - the tenantId of the merklelog, the app entry is expected
+//	               to be included on. E.g. the public tenant
+//	               for public events.
+//
+//	WithVerifyMassifHeight - the massif height of the merklelog, instead of
+//	                         discovering it from the log.
+//
+//	WithVerifyMMRSize - the size of the log to prove inclusion against, e.g. the size
+//	                    of a verified log state, instead of the size of each massif.
+func VerifyListSeq[E VerifiableAppEntry](
+	ctx context.Context,
+	reader azblob.Reader,
+	appEntries iter.Seq[E],
+	options ...VerifyOption,
+) iter.Seq2[AppEntryResult, error] {
+
+	appEntriesNoErr := func(yield func(VerifiableAppEntry, error) bool) {
+		for appEntry := range appEntries {
+			if !yield(appEntry, nil) {
+				return
+			}
+		}
+	}
+
+	return verifyListStream(ctx, reader, appEntriesNoErr, options...)
+}
+
+// VerifyListStream verifies the events in the given events json, as returned by the assetsv2 or eventsv1
+// list events API, e.g. {"events": [...]}, against a range of leaves in the immutable merkle log,
+// in the same way as VerifyListAll.
+//
+// The events json is read as the events are verified, so it is never held in memory as a whole.
+// The events must be in mmrIndex order.
+//
+// The results are yielded as they are found, in the order they are walked, with a nil error.
+// If the events json or the log could not be read, the error is yielded with an empty result
+// and the sequence stops.
+//
+// The options argument can be the following:
+//
+//	WithTenantId - the tenantId of the merklelog, the app entry is expected
+//	               to be included on. E.g. the public tenant
+//	               for public events.
+//
+//	WithVerifyMassifHeight - the massif height of the merklelog, instead of
+//	                         discovering it from the log.
+//
+//	WithVerifyMMRSize - the size of the log to prove inclusion against, e.g. the size
+//	                    of a verified log state, instead of the size of each massif.
+func VerifyListStream(
+	ctx context.Context,
+	reader azblob.Reader,
+	eventsJson io.Reader,
+	options ...VerifyOption,
+) iter.Seq2[AppEntryResult, error] {
+	appEntries := func(yield func(VerifiableAppEntry, error) bool) {
+		for appEntry, err := range EventAppEntries(eventsJson) {
+			if !yield(appEntry, err) {
+				return
+			}
+		}
+	}
+
+	return verifyListStream(ctx, reader, appEntries, options...)
+}
+
+// verifyListStream walks the leaves of the log and the given sequence of app entries in tandem.
+func verifyListStream(
+	ctx context.Context,
+	reader azblob.Reader,
+	appEntries iter.Seq2[VerifiableAppEntry, error],
+	options ...VerifyOption,
+) iter.Seq2[AppEntryResult, error] {
+
+	verifyOptions := ParseOptions(options...)
+
+	return func(yield func(AppEntryResult, error) bool) {
+
+		var walk *listWalk
+		var highestLeafIndex uint64
+
+		// the walk stops once the caller stops ranging over the results,
+		//  after which nothing more can be yielded.
+		addResult := func(result AppEntryResult) bool {
+			return yield(result, nil)
+		}
+
+		for appEntry, err := range appEntries {
+
+			if err != nil {
+				yield(AppEntryResult{}, err)
+				return
+			}
+
+			// Note: LeafCount takes an mmrIndex here not a size
+			leafIndex := mmr.LeafCount(appEntry.MMRIndex()+1) - 1
+
+			// the first app entry is the start of the leaf range
+			if walk == nil {
+				walk = newListWalk(ctx, reader, leafIndex, true, nil, verifyOptions, nil)
+			}
+
+			if leafIndex < highestLeafIndex {
+				yield(AppEntryResult{}, fmt.Errorf("%w: app id %s, mmr index %d",
+					ErrAppEntryOutOfOrder, appEntry.AppID(), appEntry.MMRIndex()))
+				return
+			}
+
+			highestLeafIndex = leafIndex
+
+			err = walk.next(appEntry, addResult)
+			if walk.stopped {
+				return
+			}
+			if err != nil {
+				yield(AppEntryResult{}, err)
+				return
+			}
+		}
+
+		// there were no app entries
+		if walk == nil {
+			return
+		}
+
+		// the leaves left in the range have no app entries
+		err := walk.omitTo(highestLeafIndex, addResult)
+		if err != nil && !walk.stopped {
+			yield(AppEntryResult{}, err)
+		}
+	}
+}
+
+// EventAppEntries reads the events in the given events json, as returned by the assetsv2 or eventsv1
+// list events API, e.g. {"events": [...]}, as app entries, one event at a time.
+//
+// If the events json could not be read, the error is yielded and the sequence stops.
+func EventAppEntries(eventsJson io.Reader) iter.Seq2[app.AppEntry, error] {
+
+	return func(yield func(app.AppEntry, error) bool) {
+
+		decoder := json.NewDecoder(eventsJson)
+
+		err := expectDelim(decoder, '{')
+		if err != nil {
+			yield(app.AppEntry{}, err)
+			return
+		}
+
+		for decoder.More() {
+
+			key, err := decoder.Token()
+			if err != nil {
+				yield(app.AppEntry{}, err)
+				return
+			}
+
+			// skip anything other than the events, e.g. the next page token
+			if key != "events" {
+				var skipped json.RawMessage
+				err = decoder.Decode(&skipped)
+				if err != nil {
+					yield(app.AppEntry{}, err)
+					return
+				}
+
+				continue
+			}
+
+			err = expectDelim(decoder, '[')
+			if err != nil {
+				yield(app.AppEntry{}, err)
+				return
+			}
+
+			for decoder.More() {
+
+				var eventJson json.RawMessage
+				err = decoder.Decode(&eventJson)
+				if err != nil {
+					yield(app.AppEntry{}, err)
+					return
+				}
+
+				appEntry, err := app.NewAppEntryFromJSON(eventJson)
+				if err != nil {
+					yield(app.AppEntry{}, err)
+					return
+				}
+
+				if !yield(*appEntry, nil) {
+					return
+				}
+			}
+
+			err = expectDelim(decoder, ']')
+			if err != nil {
+				yield(app.AppEntry{}, err)
+				return
+			}
+		}
+	}
+}
+
+// expectDelim reads the next token of the given decoder, and checks it is the given delimiter.
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	if token != delim {
+		return fmt.Errorf("%w: expected %s, got %v", ErrEventsJSONMalformed, delim, token)
+	}
+
+	return nil
+}
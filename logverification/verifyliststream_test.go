@@ -0,0 +1,153 @@
+package logverification
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVerifyListSeq tests that streaming a list of app entries gives the same results as VerifyListAll,
+// and that app entries out of mmrIndex order are rejected.
+func TestVerifyListSeq(t *testing.T) {
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	appEntries := localLog.AppendEntries(10) // 3 massifs of 4 leaves
+
+	tests := []struct {
+		name       string
+		appEntries []app.AppEntry
+		err        error
+	}{
+		{
+			name:       "all included",
+			appEntries: appEntries,
+		},
+		{
+			name: "omitted and duplicated",
+			appEntries: []app.AppEntry{
+				appEntries[1], appEntries[3], appEntries[3], appEntries[9],
+			},
+		},
+		{
+			name: "out of order",
+			appEntries: []app.AppEntry{
+				appEntries[3], appEntries[1],
+			},
+			err: ErrAppEntryOutOfOrder,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			actual := []AppEntryResult{}
+			var err error
+			for result, resultErr := range VerifyListSeq(context.Background(), localLog.Reader(), slices.Values(test.appEntries)) {
+				if resultErr != nil {
+					err = resultErr
+					break
+				}
+
+				actual = append(actual, result)
+			}
+
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+
+			expected, err := VerifyListAll(context.Background(), localLog.Reader(), test.appEntries)
+			require.NoError(t, err)
+
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+// TestVerifyListSeq_BreakEarly tests that the walk stops, without reading any more of the log,
+// once the caller stops ranging over the results, part way through a range spanning massifs.
+func TestVerifyListSeq_BreakEarly(t *testing.T) {
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	appEntries := localLog.AppendEntries(10) // 3 massifs of 4 leaves
+
+	// massif 1 can no longer be read, so the walk errors if it reaches it
+	err := os.Remove(filepath.Join(localLog.rootDir, filepath.FromSlash(massifs.TenantMassifBlobPath(localLog.tenantID, 1))))
+	require.NoError(t, err)
+
+	// every leaf between the app entries is omitted, across massif 1
+	appEntriesSeq := slices.Values([]app.AppEntry{appEntries[0], appEntries[9]})
+
+	actual := []AppEntryResult{}
+	for result, resultErr := range VerifyListSeq(context.Background(), localLog.Reader(), appEntriesSeq) {
+		require.NoError(t, resultErr)
+
+		actual = append(actual, result)
+		if result.AppEntryType == Omitted {
+			break
+		}
+	}
+
+	expected := []AppEntryResult{
+		{AppEntryType: Included, AppID: appEntries[0].AppID(), MMRIndex: appEntries[0].MMRIndex()},
+		{AppEntryType: Omitted, MMRIndex: appEntries[1].MMRIndex()},
+	}
+	assert.Equal(t, expected, actual)
+}
+
+// TestVerifyListStream tests that the events in a list events API response are verified
+// as they are read.
+func TestVerifyListStream(t *testing.T) {
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+	events := localLog.AppendEvents(6)
+
+	// omit the event at leaf 2
+	eventsJson := bytes.Join([][]byte{
+		[]byte(`{"events":[`),
+		bytes.Join([][]byte{events[0], events[1], events[3], events[4], events[5]}, []byte(",")),
+		[]byte(`],"next_page_token":""}`),
+	}, nil)
+
+	results := []AppEntryResult{}
+	for result, err := range VerifyListStream(context.Background(), localLog.Reader(), bytes.NewReader(eventsJson)) {
+		require.NoError(t, err)
+
+		results = append(results, result)
+	}
+
+	require.Len(t, results, 6)
+
+	for i, result := range results {
+
+		if i == 2 {
+			assert.Equal(t, Omitted, result.AppEntryType)
+			continue
+		}
+
+		assert.Equal(t, Included, result.AppEntryType)
+	}
+}
+
+// TestEventAppEntries_Malformed tests that a document that is not a list events API response is rejected.
+func TestEventAppEntries_Malformed(t *testing.T) {
+
+	var yieldedErr error
+	for _, err := range EventAppEntries(strings.NewReader(`{"events":{}}`)) {
+		if err != nil {
+			yieldedErr = err
+			break
+		}
+	}
+
+	require.ErrorIs(t, yieldedErr, ErrEventsJSONMalformed)
+}
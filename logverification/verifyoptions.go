@@ -5,6 +5,18 @@ type VerifyOptions struct {
 	// tenantId is an optional tenant ID to use instead
 	//  of the tenantId found on the eventJson.
 	tenantId string
+
+	// massifHeight is an optional massif height to use instead
+	//  of discovering the massif height from the log.
+	massifHeight uint8
+
+	// concurrency is an optional number of massifs to verify
+	//  at the same time, when verifying a list of app entries.
+	concurrency int
+
+	// mmrSize is an optional size of the log to prove inclusion against,
+	//  instead of the size of the massif, typically the size of a verified log state.
+	mmrSize uint64
 }
 
 type VerifyOption func(*VerifyOptions)
@@ -16,6 +28,36 @@ func WithTenantId(tenantId string) VerifyOption {
 	return func(vo *VerifyOptions) { vo.tenantId = tenantId }
 }
 
+// WithVerifyMassifHeight is an optional massif height to use instead
+//
+//	of discovering the massif height from the log.
+func WithVerifyMassifHeight(massifHeight uint8) VerifyOption {
+	return func(vo *VerifyOptions) { vo.massifHeight = massifHeight }
+}
+
+// WithVerifyConcurrency is an optional number of massifs to verify
+//
+//	at the same time, when verifying a list of app entries.
+//
+// The default, 0 or 1, verifies one leaf at a time.
+func WithVerifyConcurrency(concurrency int) VerifyOption {
+	return func(vo *VerifyOptions) { vo.concurrency = concurrency }
+}
+
+// WithVerifyMMRSize is an optional size of the log to prove inclusion against,
+//
+//	instead of the size of the massif when it was read.
+//
+// This is typically the MMRSize of a verified MMRState, so that proofs are made against the size
+// of a signed view of the log, rather than the still growing tip of the massif.
+//
+// NOTE: only the size is bound, the proofs are still verified against the peaks of the massif
+// data read from the log. To verify against the signed peaks, use VerifyProofInPeaks with
+// the peaks of the verified MMRState.
+func WithVerifyMMRSize(mmrSize uint64) VerifyOption {
+	return func(vo *VerifyOptions) { vo.mmrSize = mmrSize }
+}
+
 // ParseOptions parses the given options into a VerifyOptions struct
 func ParseOptions(options ...VerifyOption) VerifyOptions {
 	verifyOptions := VerifyOptions{}
@@ -0,0 +1,185 @@
+package logverification
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/datatrails/go-datatrails-common/cose"
+	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/datatrails/go-datatrails-merklelog/mmr"
+)
+
+/**
+ * Offline verification of COSE receipts of inclusion (MMRIVER).
+ *
+ * A receipt is verified with only the entry, the receipt and a trusted public key,
+ *  no access to the log is needed:
+ *
+ *  1. the mmr entry (leaf) is recomputed from the entry.
+ *  2. the inclusion path in the receipt is walked from the leaf to a peak.
+ *  3. the receipt signature is verified over that peak, proving the peak is one
+ *     of the peaks signed by the log.
+ */
+
+var (
+	ErrReceiptMalformed        = errors.New("the receipt is not a valid MMRIVER receipt")
+	ErrReceiptProofMissing     = errors.New("the receipt has no inclusion proof")
+	ErrReceiptMMRIndexMismatch = errors.New("the receipt inclusion proof is not for the mmr index of the entry")
+	ErrReceiptSignatureVerify  = errors.New("the receipt signature failed to verify over the proven peak")
+	ErrReceiptMMRSaltMissing   = errors.New("the receipt has no mmr salt for the leaf it proves")
+)
+
+// VerifyReceipt verifies the given CBOR receipt proves the inclusion of the given mmr entry,
+// against the given trusted public key.
+//
+// No access to the log is needed.
+//
+// Returns the mmr index the receipt proves the inclusion at, and the peak the mmr entry is included under.
+func VerifyReceipt(receiptCBOR []byte, mmrEntry []byte, publicKey crypto.PublicKey) (uint64, []byte, error) {
+
+	receipt, err := cose.NewCoseSign1MessageFromCBOR(
+		receiptCBOR, cose.WithDecOptions(massifs.CheckpointDecOptions()))
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", ErrReceiptMalformed, err)
+	}
+
+	codec, err := massifs.NewRootSignerCodec()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	proofsHeader := massifs.MMRiverVerifiableProofsHeader{}
+	err = codec.UnmarshalInto(receipt.Headers.RawUnprotected, &proofsHeader)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", ErrReceiptMalformed, err)
+	}
+
+	if len(proofsHeader.VerifiableProofs.InclusionProofs) == 0 {
+		return 0, nil, ErrReceiptProofMissing
+	}
+
+	proof := proofsHeader.VerifiableProofs.InclusionProofs[0]
+
+	// the payload is detached, the verifier supplies it by walking the
+	//  inclusion path from the mmr entry to its peak.
+	peak := mmr.IncludedRoot(sha256.New(), proof.Index, mmrEntry, proof.InclusionPath)
+	receipt.Payload = peak
+
+	err = receipt.VerifyWithPublicKey(publicKey, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: mmr index %d: %w", ErrReceiptSignatureVerify, proof.Index, err)
+	}
+
+	return proof.Index, peak, nil
+}
+
+// VerifyEventReceipt verifies the given CBOR receipt proves the inclusion of the given
+// log version 0 event json, as returned by the events API, against the given trusted public key.
+//
+// No access to the log is needed, the leaf is recomputed from the event json alone.
+//
+// Returns the peak the event is included under.
+func VerifyEventReceipt(eventJson []byte, receiptCBOR []byte, publicKey crypto.PublicKey) ([]byte, error) {
+
+	merkleLogEntry, err := MerklelogEntry(eventJson)
+	if err != nil {
+		return nil, err
+	}
+
+	idTimestamp, _, err := massifs.SplitIDTimestampHex(merkleLogEntry.Commit.Idtimestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	idTimestampBytes := make([]byte, app.IDTimestapSizeBytes)
+	binary.BigEndian.PutUint64(idTimestampBytes, idTimestamp)
+
+	mmrEntry, err := app.NewLogVersion0Hasher().HashEvent(eventJson, idTimestampBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return verifyReceiptAt(merkleLogEntry.Commit.Index, receiptCBOR, mmrEntry, publicKey)
+}
+
+// VerifyAppEntryReceipt verifies the given CBOR receipt proves the inclusion of the given
+// app entry, e.g. from eventsv1 serialized bytes, against the given trusted public key.
+//
+// The mmr entry is derived by the app.MMREntryHasher registered for the app domain in the MMR Salt.
+//
+// The MMR Salt, (extrabytes | idtimestamp), of the log entry is not part of the app data,
+// so it is taken from the receipt, see ReceiptMMRSalt.
+//
+// No access to the log is needed.
+//
+// Returns the peak the app entry is included under.
+func VerifyAppEntryReceipt(
+	appEntry *app.AppEntry,
+	receiptCBOR []byte,
+	publicKey crypto.PublicKey,
+) ([]byte, error) {
+
+	mmrSalt, err := ReceiptMMRSalt(receiptCBOR)
+	if err != nil {
+		return nil, err
+	}
+
+	mmrEntry, err := appEntry.MMREntryFromSalt(mmrSalt)
+	if err != nil {
+		return nil, err
+	}
+
+	return verifyReceiptAt(appEntry.MMRIndex(), receiptCBOR, mmrEntry, publicKey)
+}
+
+// ReceiptMMRSalt gets the MMR Salt of the leaf the given CBOR receipt proves the inclusion of.
+//
+// NOTE: the salt is not signed, it is only trusted once the receipt is verified
+// against the leaf recomputed with it.
+func ReceiptMMRSalt(receiptCBOR []byte) ([]byte, error) {
+
+	receipt, err := cose.NewCoseSign1MessageFromCBOR(
+		receiptCBOR, cose.WithDecOptions(massifs.CheckpointDecOptions()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReceiptMalformed, err)
+	}
+
+	codec, err := massifs.NewRootSignerCodec()
+	if err != nil {
+		return nil, err
+	}
+
+	saltHeader := struct {
+		MMRSalt []byte `cbor:"-65537,keyasint,omitempty"`
+	}{}
+	err = codec.UnmarshalInto(receipt.Headers.RawUnprotected, &saltHeader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReceiptMalformed, err)
+	}
+
+	if len(saltHeader.MMRSalt) == 0 {
+		return nil, ErrReceiptMMRSaltMissing
+	}
+
+	return saltHeader.MMRSalt, nil
+}
+
+// verifyReceiptAt verifies the given CBOR receipt proves the inclusion of the given mmr entry,
+// at the given mmr index.
+func verifyReceiptAt(mmrIndex uint64, receiptCBOR []byte, mmrEntry []byte, publicKey crypto.PublicKey) ([]byte, error) {
+
+	receiptMMRIndex, peak, err := VerifyReceipt(receiptCBOR, mmrEntry, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if receiptMMRIndex != mmrIndex {
+		return nil, fmt.Errorf("%w: receipt %d, entry %d", ErrReceiptMMRIndexMismatch, receiptMMRIndex, mmrIndex)
+	}
+
+	return peak, nil
+}
@@ -0,0 +1,146 @@
+package logverification
+
+import (
+	"context"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"github.com/datatrails/go-datatrails-common/logger"
+	"github.com/datatrails/go-datatrails-logverification/logverification/app"
+	"github.com/datatrails/go-datatrails-merklelog/massifs"
+	"github.com/datatrails/go-datatrails-merklelog/mmr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVerifyEventReceipt tests that log version 0 event receipts verify
+// offline, with only the event json, the receipt and the public key.
+func TestVerifyEventReceipt(t *testing.T) {
+	logger.New("TestVerifyEventReceipt")
+	defer logger.OnExit()
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+
+	events := localLog.AppendEvents(3)
+	logState := localLog.Seal()
+
+	receipt, err := EventReceipt(context.Background(), events[2], localLog.Reader())
+	require.NoError(t, err)
+
+	otherReceipt, err := EventReceipt(context.Background(), events[0], localLog.Reader())
+	require.NoError(t, err)
+
+	otherKey := massifs.TestGenerateECKey(t, elliptic.P256())
+
+	tests := []struct {
+		name      string
+		eventJson []byte
+		receipt   []byte
+		publicKey any
+		err       error
+	}{
+		{
+			name:      "positive",
+			eventJson: events[2],
+			receipt:   receipt,
+			publicKey: &localLog.signingKey.PublicKey,
+		},
+		{
+			name:      "tampered event",
+			eventJson: []byte(strings.Replace(string(events[2]), "RecordEvidence", "RecordEvidenceX", 1)),
+			receipt:   receipt,
+			publicKey: &localLog.signingKey.PublicKey,
+			err:       ErrReceiptSignatureVerify,
+		},
+		{
+			name:      "receipt for another event",
+			eventJson: events[2],
+			receipt:   otherReceipt,
+			publicKey: &localLog.signingKey.PublicKey,
+			err:       ErrReceiptSignatureVerify,
+		},
+		{
+			name:      "untrusted key",
+			eventJson: events[2],
+			receipt:   receipt,
+			publicKey: &otherKey.PublicKey,
+			err:       ErrReceiptSignatureVerify,
+		},
+		{
+			name:      "not a receipt",
+			eventJson: events[2],
+			receipt:   []byte("not a receipt"),
+			publicKey: &localLog.signingKey.PublicKey,
+			err:       ErrReceiptMalformed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			peak, err := VerifyEventReceipt(test.eventJson, test.receipt, test.publicKey)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Contains(t, logState.Peaks, peak)
+		})
+	}
+}
+
+// TestVerifyAppEntryReceipt tests that log version 1 app entry receipts verify
+// offline, with only the app entry, the receipt and the public key.
+func TestVerifyAppEntryReceipt(t *testing.T) {
+	logger.New("TestVerifyAppEntryReceipt")
+	defer logger.OnExit()
+
+	localLog := newTestLocalLog(t, testLocalLogMassifHeight)
+
+	appEntries := localLog.AppendEntries(3)
+	logState := localLog.Seal()
+
+	appEntry := appEntries[1]
+
+	receipt, err := Receipt(context.Background(), localLog.Reader(), localLog.tenantID, appEntry.MMRIndex())
+	require.NoError(t, err)
+
+	// the receipt carries the mmr salt of the log entry
+	expectedMMRSalt, err := appEntry.MMRSalt(localLog.Massif(0))
+	require.NoError(t, err)
+
+	mmrSalt, err := ReceiptMMRSalt(receipt)
+	require.NoError(t, err)
+	assert.Equal(t, expectedMMRSalt, mmrSalt)
+
+	peak, err := VerifyAppEntryReceipt(&appEntry, receipt, &localLog.signingKey.PublicKey)
+	require.NoError(t, err)
+	assert.Contains(t, logState.Peaks, peak)
+
+	// the receipt must not verify for another app entry
+	_, err = VerifyAppEntryReceipt(&appEntries[0], receipt, &localLog.signingKey.PublicKey)
+	assert.ErrorIs(t, err, ErrReceiptSignatureVerify)
+
+	signedState, err := SignedLogState(
+		context.Background(), localLog.Reader(), sha256.New(), localLog.codec, localLog.tenantID, 0)
+	require.NoError(t, err)
+
+	proof, err := mmr.InclusionProof(localLog.Massif(0), logState.MMRSize-1, appEntry.MMRIndex())
+	require.NoError(t, err)
+
+	// the mmr salt must be in the receipt
+	noSaltReceipt, err := NewInclusionReceipt(signedState, localLog.codec, appEntry.MMRIndex(), proof, nil)
+	require.NoError(t, err)
+
+	_, err = VerifyAppEntryReceipt(&appEntry, noSaltReceipt, &localLog.signingKey.PublicKey)
+	assert.ErrorIs(t, err, ErrReceiptMMRSaltMissing)
+
+	// the mmr salt must be complete
+	shortSaltReceipt, err := NewInclusionReceipt(signedState, localLog.codec, appEntry.MMRIndex(), proof, mmrSalt[1:])
+	require.NoError(t, err)
+
+	_, err = VerifyAppEntryReceipt(&appEntry, shortSaltReceipt, &localLog.signingKey.PublicKey)
+	assert.ErrorIs(t, err, app.ErrMMRSaltSize)
+}